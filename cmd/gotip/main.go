@@ -1,9 +1,13 @@
 package main
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"io"
+	"maps"
 	"os"
+	"os/signal"
 	"slices"
 
 	"github.com/jessevdk/go-flags"
@@ -12,20 +16,28 @@ import (
 	"github.com/lusingander/gotip/internal/parse"
 	"github.com/lusingander/gotip/internal/tip"
 	"github.com/lusingander/gotip/internal/ui"
+	"github.com/lusingander/gotip/internal/watch"
 )
 
 type options struct {
-	View         string   `short:"v" long:"view" description:"Default view" choice:"all" choice:"history" default:"all"`
+	View         string   `short:"v" long:"view" description:"Default view" choice:"all" choice:"history" choice:"tree" default:"all"`
 	Filter       string   `short:"f" long:"filter" description:"Default filter type" choice:"fuzzy" choice:"exact" default:"fuzzy"`
 	Packages     []string `short:"p" long:"package" value-name:"PACKAGE" description:"Filter by package name"`
 	SkipSubtests bool     `short:"s" long:"skip-subtests" description:"Skip subtest detection"`
+	Discovery    string   `long:"discovery" description:"Test discovery method" choice:"syntax" choice:"types" default:"syntax"`
+	NoCache      bool     `long:"no-cache" description:"Discover tests without using the cache of the last discovery"`
 	Rerun        bool     `short:"r" long:"rerun" description:"Rerun the last test without showing the UI"`
+	RerunFailed  bool     `long:"rerun-failed" description:"Rerun the tests that failed in the last run without showing the UI"`
+	Loop         bool     `short:"l" long:"loop" description:"Show test results in the UI and return to the list after running"`
+	Watch        bool     `short:"w" long:"watch" description:"Rerun the test whenever Go files in its package or dependencies change"`
 	Version      bool     `short:"V" long:"version" description:"Print version"`
 }
 
 type listOptions struct {
 	Packages     []string `short:"p" long:"package" value-name:"PACKAGE" description:"Filter by package name"`
 	SkipSubtests bool     `short:"s" long:"skip-subtests" description:"Skip subtest detection"`
+	Discovery    string   `long:"discovery" description:"Test discovery method" choice:"syntax" choice:"types" default:"syntax"`
+	NoCache      bool     `long:"no-cache" description:"Discover tests without using the cache of the last discovery"`
 	Format       string   `long:"format" description:"Output format" choice:"text" choice:"json" default:"text"`
 }
 
@@ -81,6 +93,63 @@ func parseArgs(args []string) (*parsedArgs, error) {
 	}, nil
 }
 
+// startDiscovery starts finding the tests in the packages in the current directory in the background with the discovery method,
+// which is either "syntax" for parsing the files only, or "types" for using the type information of the packages as well.
+// The syntax discovery reuses the results of the last run for unchanged packages unless noCache is set.
+// The results are sent to the returned channel, which is closed once the discovery is done and the cache is saved.
+// When ctx is canceled, the results are no longer sent, and the channel is closed once the cache is saved with the packages discovered so far.
+func startDiscovery(ctx context.Context, discovery string, conf *tip.Config, skipSubtests, noCache bool, packages []string) <-chan *tip.DiscoveryResult {
+	results := make(chan *tip.DiscoveryResult)
+	send := func(result *tip.DiscoveryResult) {
+		select {
+		case results <- result:
+		case <-ctx.Done():
+		}
+	}
+	go func() {
+		defer close(results)
+
+		var cache *tip.DiscoveryCache
+		var discovered <-chan *tip.DiscoveryResult
+		switch {
+		case discovery == "types":
+			discovered = parse.DiscoverPackagesRecursively(ctx, ".", conf.Ignore, skipSubtests, conf.Build)
+		case noCache:
+			discovered = parse.DiscoverFilesRecursively(ctx, ".", conf.Ignore, skipSubtests, conf.Build, nil)
+		default:
+			var err error
+			if cache, err = tip.LoadDiscoveryCache("."); err != nil {
+				send(&tip.DiscoveryResult{Errors: []error{err}})
+				return
+			}
+			discovered = parse.DiscoverFilesRecursively(ctx, ".", conf.Ignore, skipSubtests, conf.Build, cache)
+		}
+
+		for result := range discovered {
+			result.Tests = tip.FilterTestsByPackages(result.Tests, packages)
+			maps.DeleteFunc(result.Diagnostics, func(path string, _ []tip.Diagnostic) bool {
+				_, ok := result.Tests[path]
+				return !ok
+			})
+			send(result)
+		}
+		if cache != nil {
+			if err := tip.SaveDiscoveryCache(".", cache); err != nil {
+				send(&tip.DiscoveryResult{Errors: []error{err}})
+			}
+		}
+	}()
+	return results
+}
+
+// stopDiscovery stops the discovery which is no longer shown, and waits until the cache is saved.
+func stopDiscovery(cancel context.CancelFunc, discovery <-chan *tip.DiscoveryResult) {
+	cancel()
+	for range discovery {
+		// results sent before the cancellation is noticed
+	}
+}
+
 func run(args []string) (int, error) {
 	parsed, err := parseArgs(args)
 	if err != nil {
@@ -106,23 +175,31 @@ func run(args []string) (int, error) {
 			return 1, errors.New("list does not accept test arguments after --")
 		}
 		skipSubtests := opt.SkipSubtests || parsed.ListOptions.SkipSubtests
-		tests, err := parse.ProcessFilesRecursively(".", conf.Ignore, skipSubtests)
-		if err != nil {
-			return 1, err
+		discovery := opt.Discovery
+		if parsed.ListOptions.Discovery != "syntax" {
+			discovery = parsed.ListOptions.Discovery
 		}
+		noCache := opt.NoCache || parsed.ListOptions.NoCache
 		packages := append([]string{}, opt.Packages...)
 		packages = append(packages, parsed.ListOptions.Packages...)
-		tests = tip.FilterTestsByPackages(tests, packages)
+		result := tip.CollectDiscoveryResults(startDiscovery(context.Background(), discovery, conf, skipSubtests, noCache, packages))
+		// the tests in the other files are listed, and the exit code tells the list is incomplete
+		for _, err := range result.Errors {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		}
 		switch parsed.ListOptions.Format {
 		case "text":
-			if err := listfmt.WriteText(os.Stdout, tests); err != nil {
+			if err := listfmt.WriteText(os.Stdout, result.Tests); err != nil {
 				return 1, err
 			}
 		case "json":
-			if err := listfmt.WriteJSON(os.Stdout, tests); err != nil {
+			if err := listfmt.WriteJSON(os.Stdout, result.Tests, result.Diagnostics); err != nil {
 				return 1, err
 			}
 		}
+		if len(result.Errors) > 0 {
+			return 1, nil
+		}
 		return 0, nil
 	}
 
@@ -130,43 +207,111 @@ func run(args []string) (int, error) {
 	if err != nil {
 		return 1, err
 	}
+	records, err := tip.LoadTestRecords(".")
+	if err != nil {
+		return 1, err
+	}
+	saveRecords := func(result *tip.RunResult) error {
+		records.Add(result)
+		return tip.SaveTestRecords(".", records)
+	}
 
 	if opt.Rerun {
 		if len(histories.Histories) == 0 {
 			fmt.Fprintln(os.Stderr, "No test history found.")
 			return 1, nil
 		}
-		code, err := command.Test(histories.Histories[0].ToTarget(), parsed.TestArgs, conf)
+		return runTargets(histories.Histories[0].ToTargets(), parsed.TestArgs, conf, opt.Watch, saveRecords)
+	}
+
+	if opt.RerunFailed {
+		targets := records.FailedTargets()
+		if len(targets) == 0 {
+			fmt.Fprintln(os.Stderr, "No failed tests found in the last run.")
+			return 0, nil
+		}
+		return runTargets(targets, parsed.TestArgs, conf, opt.Watch, saveRecords)
+	}
+
+	discoveryCtx, cancelDiscovery := context.WithCancel(context.Background())
+	discovery := startDiscovery(discoveryCtx, opt.Discovery, conf, opt.SkipSubtests, opt.NoCache, opt.Packages)
+	displayHistories := tip.FilterHistoriesByPackages(histories, opt.Packages)
+
+	if opt.Loop {
+		loop := &ui.Loop{
+			Run: func(ctx context.Context, targets []*tip.Target, w io.Writer) (*tip.RunResult, error) {
+				return command.TestWithOutput(ctx, targets, parsed.TestArgs, conf, w)
+			},
+			// the histories and the records are read by the UI, which shows the tests discovered while running
+			Save: func(targets []*tip.Target, result *tip.RunResult) error {
+				return saveRun(histories, records, targets, result, conf)
+			},
+			Histories: func() *tip.Histories {
+				return tip.FilterHistoriesByPackages(histories, opt.Packages)
+			},
+			Records: func() *tip.TestRecords {
+				return records
+			},
+			Watch: func(targets []*tip.Target) (*watch.Watcher, error) {
+				dirs, err := command.WatchDirs(targets, conf.Build)
+				if err != nil {
+					return nil, err
+				}
+				return watch.New(dirs, watch.DefaultDebounce)
+			},
+			AutoWatch: opt.Watch,
+		}
+		err := ui.StartLoop(discovery, displayHistories, records, conf, loop, opt.View, opt.Filter)
+		stopDiscovery(cancelDiscovery, discovery)
 		if err != nil {
 			return 1, err
 		}
-		return code, nil
+		return 0, nil
 	}
 
-	tests, err := parse.ProcessFilesRecursively(".", conf.Ignore, opt.SkipSubtests)
+	targets, err := ui.Start(discovery, displayHistories, records, conf, opt.View, opt.Filter)
+	// the tests picked before the discovery is done are run without waiting for the rest
+	stopDiscovery(cancelDiscovery, discovery)
 	if err != nil {
 		return 1, err
 	}
-	tests = tip.FilterTestsByPackages(tests, opt.Packages)
-	displayHistories := tip.FilterHistoriesByPackages(histories, opt.Packages)
-
-	target, err := ui.Start(tests, displayHistories, conf, opt.View, opt.Filter)
-	if err != nil {
-		return 1, err
+	if len(targets) == 0 {
+		return 0, nil
 	}
-	if target == nil {
+
+	return runTargets(targets, parsed.TestArgs, conf, opt.Watch, func(result *tip.RunResult) error {
+		return saveRun(histories, records, targets, result, conf)
+	})
+}
+
+// runTargets runs the targets once, or every time files change until interrupted in watch mode.
+// onRun is called with the result of each run.
+func runTargets(targets []*tip.Target, testArgs []string, conf *tip.Config, watchMode bool, onRun func(*tip.RunResult) error) (int, error) {
+	if watchMode {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		if err := command.Watch(ctx, targets, testArgs, conf, onRun); err != nil {
+			return 1, err
+		}
 		return 0, nil
 	}
 
-	code, err := command.Test(target, parsed.TestArgs, conf)
+	result, err := command.Test(targets, testArgs, conf)
 	if err != nil {
 		return 1, err
 	}
-
-	histories.Add(target, conf.History.Limit)
-	if err := tip.SaveHistories(".", histories); err != nil {
+	if err := onRun(result); err != nil {
 		return 1, err
 	}
+	return result.ExitCode, nil
+}
 
-	return code, nil
+// saveRun records the targets in the history and the outcome of each test in the result.
+func saveRun(histories *tip.Histories, records *tip.TestRecords, targets []*tip.Target, result *tip.RunResult, conf *tip.Config) error {
+	histories.AddSet(targets, conf.History.Limit)
+	if err := tip.SaveHistories(".", histories); err != nil {
+		return err
+	}
+	records.Add(result)
+	return tip.SaveTestRecords(".", records)
 }
@@ -73,3 +73,23 @@ func TestParseArgs_defaultExecutionArgs(t *testing.T) {
 		}
 	}
 }
+
+func TestParseArgs_discovery(t *testing.T) {
+	got, err := parseArgs([]string{"gotip", "--discovery=types", "list", "--no-cache"})
+	if err != nil {
+		t.Fatalf("parseArgs() error = %v", err)
+	}
+	if got.Options.Discovery != "types" {
+		t.Errorf("root discovery = %q, want %q", got.Options.Discovery, "types")
+	}
+	if got.ListOptions.Discovery != "syntax" {
+		t.Errorf("list discovery = %q, want %q", got.ListOptions.Discovery, "syntax")
+	}
+	if !got.ListOptions.NoCache {
+		t.Error("list no-cache = false, want true")
+	}
+
+	if _, err := parseArgs([]string{"gotip", "--discovery=unknown"}); err == nil {
+		t.Error("parseArgs() error = nil, want error for unknown discovery")
+	}
+}
@@ -1,9 +1,13 @@
 package command
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
+	"regexp"
+	"slices"
 	"strings"
 
 	"github.com/charmbracelet/lipgloss"
@@ -17,55 +21,187 @@ const (
 
 var outputStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#00A29C"))
 
-func Test(target *tip.Target, extraArgs []string, conf *tip.Config) (int, error) {
-	if target == nil {
-		return 0, nil
+// Test runs the targets and returns the results parsed from the go test -json output.
+func Test(targets []*tip.Target, extraArgs []string, conf *tip.Config) (*tip.RunResult, error) {
+	return runTest(context.Background(), targets, extraArgs, conf, os.Stdin, os.Stdout, os.Stderr)
+}
+
+// TestWithOutput runs the targets like Test, but writes all output to w instead of the terminal.
+// The test process is killed when ctx is canceled.
+func TestWithOutput(ctx context.Context, targets []*tip.Target, extraArgs []string, conf *tip.Config, w io.Writer) (*tip.RunResult, error) {
+	return runTest(ctx, targets, extraArgs, conf, nil, w, w)
+}
+
+func runTest(ctx context.Context, targets []*tip.Target, extraArgs []string, conf *tip.Config, stdin io.Reader, stdout, stderr io.Writer) (*tip.RunResult, error) {
+	result := &tip.RunResult{}
+
+	// the import path is only used to shorten package names, so it is fine if it cannot be read
+	dirImportPath, _ := tip.DirImportPath(".")
+
+	for _, run := range planTestRuns(targets) {
+		events := newTestEventWriter(stdout, isVerbose(extraArgs), dirImportPath)
+
+		cmd := buildTestExecCommand(ctx, run, extraArgs, conf)
+		cmd.Stdin = stdin
+		cmd.Stdout = events
+		cmd.Stderr = stderr
+
+		fmt.Fprintln(stderr, outputStyle.Render(cmd.String()))
+		err := cmd.Run()
+		if err != nil {
+			if _, ok := err.(*exec.ExitError); !ok {
+				return nil, err
+			}
+		}
+		if err := events.Flush(); err != nil {
+			return nil, err
+		}
+
+		result.Tests = append(result.Tests, events.result.Tests...)
+		result.Packages = append(result.Packages, events.result.Packages...)
+		if result.ExitCode == 0 {
+			// report the first failure
+			result.ExitCode = cmd.ProcessState.ExitCode()
+		}
+		if ctx.Err() != nil {
+			// do not start the remaining runs once canceled
+			break
+		}
 	}
 
-	nameRegex := testNameToTestRunRegex(target.TestNamePattern, target.IsPrefix)
+	if err := writeSummary(stderr, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
 
-	cmd := buildTestExecCommand(target, nameRegex, extraArgs, conf.Command)
-	cmd.Stdin = os.Stdin
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+// testRun is a single invocation of go test.
+type testRun struct {
+	packages  []string
+	nameRegex string
+	kind      tip.TestKind
+	isFuzzing bool
+}
 
-	fmt.Fprintln(os.Stderr, outputStyle.Render(cmd.String()))
-	err := cmd.Run()
-	if err != nil {
-		if _, ok := err.(*exec.ExitError); !ok {
-			return 1, err
+// planTestRuns groups the targets into as few go test invocations as possible.
+// Targets with the same parent test in the same package are merged into one name pattern,
+// and packages with the same name pattern are run together.
+func planTestRuns(targets []*tip.Target) []*testRun {
+	type group struct {
+		packageName string
+		targets     []*tip.Target
+	}
+	groups := make([]*group, 0)
+	groupIndex := make(map[string]int)
+	for i, target := range targets {
+		if isCoveredTarget(targets, i) {
+			continue
+		}
+		segments, _ := targetNameSegments(target)
+		// the pattern matches each level independently, so only the targets differing in the last level can be merged exactly
+		parent := strings.Join(segments[:max(len(segments)-1, 0)], "/")
+		key := fmt.Sprintf("%s %q", target.PackageName, parent)
+		if target.IsFuzzing {
+			// go test cannot fuzz more than one test at a time
+			key = fmt.Sprintf("%s fuzz %d", key, len(groups))
+		} else if target.Kind == tip.TestKindBenchmark {
+			key += " bench"
 		}
+		if i, ok := groupIndex[key]; ok {
+			groups[i].targets = append(groups[i].targets, target)
+			continue
+		}
+		groupIndex[key] = len(groups)
+		groups = append(groups, &group{packageName: target.PackageName, targets: []*tip.Target{target}})
 	}
-	return cmd.ProcessState.ExitCode(), nil
+
+	runs := make([]*testRun, 0)
+	runIndex := make(map[string]int)
+	for _, g := range groups {
+		first := g.targets[0]
+		run := &testRun{
+			packages:  []string{g.packageName},
+			nameRegex: targetsToTestRunRegex(g.targets),
+			kind:      first.Kind,
+			isFuzzing: first.IsFuzzing,
+		}
+		if run.isFuzzing {
+			runs = append(runs, run)
+			continue
+		}
+		key := fmt.Sprintf("%t %s", run.kind == tip.TestKindBenchmark, run.nameRegex)
+		if i, ok := runIndex[key]; ok {
+			runs[i].packages = append(runs[i].packages, g.packageName)
+			continue
+		}
+		runIndex[key] = len(runs)
+		runs = append(runs, run)
+	}
+	return runs
 }
 
-func buildTestExecCommand(target *tip.Target, nameRegex string, extraArgs []string, command []string) *exec.Cmd {
+func buildTestExecCommand(ctx context.Context, run *testRun, extraArgs []string, conf *tip.Config) *exec.Cmd {
+	command := conf.Command
 	if len(command) == 0 {
-		// default Go test command
-		args := []string{"test"}
-		if target.TestNamePattern != "" {
-			args = append(args, "-run", nameRegex)
+		// default Go test command, with -json to collect the results
+		args := []string{"test", "-json"}
+		// the same build tags as the tests are discovered with
+		args = append(args, conf.Build.BuildFlags()...)
+		if run.nameRegex != "" || run.kind == tip.TestKindBenchmark {
+			args = append(args, testNameFlags(run, conf.Fuzz)...)
 		}
-		args = append(args, target.PackageName)
+		args = append(args, run.packages...)
 
-		return exec.Command("go", append(args, extraArgs...)...)
+		cmd := exec.CommandContext(ctx, "go", append(args, extraArgs...)...)
+		cmd.Env = conf.Build.Env()
+		return cmd
 	}
 
 	// custom command from configuration
 	args := make([]string, 0)
-	if len(command) > 1 {
-		for _, arg := range command[1:] {
-			switch arg {
-			case commandTestNameMarker:
-				args = append(args, nameRegex)
-			case commandPackageMarker:
-				args = append(args, target.PackageName)
-			default:
+	for i := 1; i < len(command); i++ {
+		switch arg := command[i]; arg {
+		case commandTestNameMarker:
+			args = append(args, run.nameRegex)
+		case commandPackageMarker:
+			args = append(args, run.packages...)
+		case "-run":
+			// `-run ${name}` is replaced as a whole so that benchmarks are selected with -bench
+			if i+1 < len(command) && command[i+1] == commandTestNameMarker {
+				args = append(args, testNameFlags(run, conf.Fuzz)...)
+				i++
+			} else {
 				args = append(args, arg)
 			}
+		default:
+			args = append(args, arg)
+		}
+	}
+	cmd := exec.CommandContext(ctx, command[0], append(args, extraArgs...)...)
+	cmd.Env = conf.Build.Env()
+	return cmd
+}
+
+func testNameFlags(run *testRun, fuzzConf tip.FuzzConfig) []string {
+	nameRegex := run.nameRegex
+	if run.isFuzzing {
+		// run the seed corpus of the fuzz test only, then start fuzzing it
+		args := []string{"-run", nameRegex, "-fuzz", nameRegex}
+		if fuzzConf.Time != "" {
+			args = append(args, "-fuzztime", fuzzConf.Time)
+		}
+		return args
+	}
+	switch run.kind {
+	case tip.TestKindBenchmark:
+		if nameRegex == "" {
+			nameRegex = "."
 		}
+		// skip regular tests and run only the selected benchmarks
+		return []string{"-run", "^$", "-bench", nameRegex}
+	default:
+		return []string{"-run", nameRegex}
 	}
-	return exec.Command(command[0], append(args, extraArgs...)...)
 }
 
 func testNameToTestRunRegex(pattern string, isPrefix bool) string {
@@ -75,10 +211,98 @@ func testNameToTestRunRegex(pattern string, isPrefix bool) string {
 			continue
 		}
 		if isPrefix && i == len(segments)-1 {
-			segments[i] = "^" + segment
+			segments[i] = "^" + quoteTestNameSegment(segment)
 		} else {
-			segments[i] = "^" + segment + "$"
+			segments[i] = "^" + quoteTestNameSegment(segment) + "$"
 		}
 	}
 	return strings.Join(segments, "/")
 }
+
+// quoteTestNameSegment returns a pattern that matches a slash-separated level of a test name literally.
+// go test splits the -run pattern at slashes outside of brackets and parentheses,
+// and escaped metacharacters do not open them, so each level can be quoted independently.
+func quoteTestNameSegment(segment string) string {
+	return regexp.QuoteMeta(segment)
+}
+
+// isCoveredTarget reports whether all tests of targets[i] are run by another target anyway, such as TestA/x by TestA.
+// Of the same targets, the first one is kept.
+func isCoveredTarget(targets []*tip.Target, i int) bool {
+	for j, other := range targets {
+		if j != i && coversTarget(other, targets[i]) && (j < i || !coversTarget(targets[i], other)) {
+			return true
+		}
+	}
+	return false
+}
+
+func coversTarget(a, b *tip.Target) bool {
+	if a.PackageName != b.PackageName || a.IsFuzzing || b.IsFuzzing || (a.Kind == tip.TestKindBenchmark) != (b.Kind == tip.TestKindBenchmark) {
+		return false
+	}
+	aSegments, aPartial := targetNameSegments(a)
+	bSegments, bPartial := targetNameSegments(b)
+	if aPartial || len(aSegments) > len(bSegments) || (bPartial && len(aSegments) == len(bSegments)) {
+		return false
+	}
+	// all subtests of a selected test are run
+	return slices.Equal(aSegments, bSegments[:len(aSegments)])
+}
+
+// targetNameSegments returns the levels of the target name, and whether the last level is a prefix of names.
+func targetNameSegments(target *tip.Target) ([]string, bool) {
+	if target.TestNamePattern == "" {
+		// all tests in the package
+		return nil, false
+	}
+	segments := strings.Split(target.TestNamePattern, "/")
+	if target.IsPrefix && segments[len(segments)-1] == "" {
+		// all subtests of the parent are selected
+		return segments[:len(segments)-1], false
+	}
+	return segments, target.IsPrefix
+}
+
+// targetsToTestRunRegex returns a pattern that selects all of the targets.
+// go test matches each slash-separated level of a name independently, so the alternatives are merged per level.
+// The pattern selects exactly the targets if they differ only in the last level, as planTestRuns groups them.
+func targetsToTestRunRegex(targets []*tip.Target) string {
+	if len(targets) == 1 {
+		return testNameToTestRunRegex(targets[0].TestNamePattern, targets[0].IsPrefix)
+	}
+
+	targetSegments := make([][]string, 0, len(targets))
+	// whether the last segment of the target is a prefix of names
+	partial := make([]bool, 0, len(targets))
+	depth := -1
+	for _, target := range targets {
+		segments, isPartial := targetNameSegments(target)
+		targetSegments = append(targetSegments, segments)
+		partial = append(partial, isPartial)
+		if depth < 0 || len(segments) < depth {
+			depth = len(segments)
+		}
+	}
+
+	// levels deeper than the shallowest target are not restricted, so that all subtests of it are run
+	levels := make([]string, 0, depth)
+	for level := range depth {
+		alternatives := make([]string, 0)
+		for i, segments := range targetSegments {
+			alternative := quoteTestNameSegment(segments[level])
+			if partial[i] && level == len(segments)-1 {
+				alternative += ".*"
+			}
+			if !slices.Contains(alternatives, alternative) {
+				alternatives = append(alternatives, alternative)
+			}
+		}
+		if len(alternatives) == 1 {
+			levels = append(levels, "^"+alternatives[0]+"$")
+		} else {
+			levels = append(levels, "^("+strings.Join(alternatives, "|")+")$")
+		}
+	}
+	return strings.Join(levels, "/")
+}
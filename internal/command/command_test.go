@@ -0,0 +1,314 @@
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"os/exec"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/lusingander/gotip/internal/tip"
+)
+
+func TestBuildTestExecCommand(t *testing.T) {
+	tests := []struct {
+		name    string
+		target  *tip.Target
+		command []string
+		build   tip.BuildConfig
+		want    []string
+	}{
+		{
+			name:   "test",
+			target: tip.NewTarget("./foo/foo_test.go", "TestFoo/bar", tip.TestKindTest, false),
+			want:   []string{"go", "test", "-json", "-run", "^TestFoo$/^bar$", "./foo", "-v"},
+		},
+		{
+			name:   "benchmark",
+			target: tip.NewTarget("./foo/foo_test.go", "BenchmarkFoo/bar", tip.TestKindBenchmark, false),
+			want:   []string{"go", "test", "-json", "-run", "^$", "-bench", "^BenchmarkFoo$/^bar$", "./foo", "-v"},
+		},
+		{
+			name:   "all benchmarks in package",
+			target: tip.NewTarget("./foo/foo_test.go", "", tip.TestKindBenchmark, true),
+			want:   []string{"go", "test", "-json", "-run", "^$", "-bench", ".", "./foo", "-v"},
+		},
+		{
+			name:   "fuzz seed corpus entry",
+			target: tip.NewTarget("./foo/foo_test.go", "FuzzFoo/0123abcd", tip.TestKindFuzz, false),
+			want:   []string{"go", "test", "-json", "-run", "^FuzzFoo$/^0123abcd$", "./foo", "-v"},
+		},
+		{
+			name:   "fuzzing",
+			target: tip.NewTarget("./foo/foo_test.go", "FuzzFoo/0123abcd", tip.TestKindFuzz, false).FuzzTarget(),
+			want:   []string{"go", "test", "-json", "-run", "^FuzzFoo$", "-fuzz", "^FuzzFoo$", "-fuzztime", "10s", "./foo", "-v"},
+		},
+		{
+			name:   "test with build tags",
+			target: tip.NewTarget("./foo/foo_test.go", "TestFoo", tip.TestKindTest, false),
+			build:  tip.BuildConfig{Tags: []string{"integration", "e2e"}},
+			want:   []string{"go", "test", "-json", "-tags=integration,e2e", "-run", "^TestFoo$", "./foo", "-v"},
+		},
+		{
+			name:    "custom command with test",
+			target:  tip.NewTarget("./foo/foo_test.go", "TestFoo", tip.TestKindTest, false),
+			command: []string{"gotestsum", "--", "-run", "${name}", "${package}"},
+			want:    []string{"gotestsum", "--", "-run", "^TestFoo$", "./foo", "-v"},
+		},
+		{
+			name:    "custom command with benchmark",
+			target:  tip.NewTarget("./foo/foo_test.go", "BenchmarkFoo", tip.TestKindBenchmark, false),
+			command: []string{"gotestsum", "--", "-run", "${name}", "${package}"},
+			want:    []string{"gotestsum", "--", "-run", "^$", "-bench", "^BenchmarkFoo$", "./foo", "-v"},
+		},
+		{
+			name:    "custom command with fuzzing",
+			target:  tip.NewTarget("./foo/foo_test.go", "FuzzFoo", tip.TestKindFuzz, false).FuzzTarget(),
+			command: []string{"gotestsum", "--", "-run", "${name}", "${package}"},
+			want:    []string{"gotestsum", "--", "-run", "^FuzzFoo$", "-fuzz", "^FuzzFoo$", "-fuzztime", "10s", "./foo", "-v"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf := &tip.Config{Command: tt.command, Fuzz: tip.FuzzConfig{Time: "10s"}, Build: tt.build}
+			runs := planTestRuns([]*tip.Target{tt.target})
+			if len(runs) != 1 {
+				t.Fatalf("runs len = %d, want 1", len(runs))
+			}
+			cmd := buildTestExecCommand(context.Background(), runs[0], []string{"-v"}, conf)
+			if !slices.Equal(cmd.Args, tt.want) {
+				t.Errorf("args = %q, want %q", cmd.Args, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildTestExecCommand_buildEnv(t *testing.T) {
+	target := tip.NewTarget("./foo/foo_test.go", "TestFoo", tip.TestKindTest, false)
+	runs := planTestRuns([]*tip.Target{target})
+
+	cmd := buildTestExecCommand(context.Background(), runs[0], nil, &tip.Config{})
+	if cmd.Env != nil {
+		t.Errorf("env = %q, want nil to inherit the current environment", cmd.Env)
+	}
+
+	conf := &tip.Config{Build: tip.BuildConfig{GOOS: "linux", GOARCH: "arm64"}}
+	cmd = buildTestExecCommand(context.Background(), runs[0], nil, conf)
+	for _, want := range []string{"GOOS=linux", "GOARCH=arm64"} {
+		if !slices.Contains(cmd.Env, want) {
+			t.Errorf("env does not contain %q", want)
+		}
+	}
+}
+
+func TestPlanTestRuns(t *testing.T) {
+	tests := []struct {
+		name    string
+		targets []*tip.Target
+		want    [][]string
+	}{
+		{
+			name: "tests in the same package",
+			targets: []*tip.Target{
+				tip.NewTarget("./foo/foo_test.go", "TestA", tip.TestKindTest, false),
+				tip.NewTarget("./foo/foo_test.go", "TestB", tip.TestKindTest, false),
+			},
+			want: [][]string{
+				{"go", "test", "-json", "-run", "^(TestA|TestB)$", "./foo"},
+			},
+		},
+		{
+			name: "subtests of the same test",
+			targets: []*tip.Target{
+				tip.NewTarget("./foo/foo_test.go", "TestA/x", tip.TestKindTest, false),
+				tip.NewTarget("./foo/foo_test.go", "TestA/y", tip.TestKindTest, false),
+			},
+			want: [][]string{
+				{"go", "test", "-json", "-run", "^TestA$/^(x|y)$", "./foo"},
+			},
+		},
+		{
+			name: "subtests of different tests",
+			targets: []*tip.Target{
+				tip.NewTarget("./foo/foo_test.go", "TestA/x", tip.TestKindTest, false),
+				tip.NewTarget("./foo/foo_test.go", "TestB/y", tip.TestKindTest, false),
+			},
+			want: [][]string{
+				{"go", "test", "-json", "-run", "^TestA$/^x$", "./foo"},
+				{"go", "test", "-json", "-run", "^TestB$/^y$", "./foo"},
+			},
+		},
+		{
+			name: "subtests at different levels",
+			targets: []*tip.Target{
+				tip.NewTarget("./foo/foo_test.go", "TestA/x/1", tip.TestKindTest, false),
+				tip.NewTarget("./foo/foo_test.go", "TestA/y", tip.TestKindTest, false),
+				tip.NewTarget("./foo/foo_test.go", "TestA/x/1", tip.TestKindTest, false),
+			},
+			want: [][]string{
+				{"go", "test", "-json", "-run", "^TestA$/^x$/^1$", "./foo"},
+				{"go", "test", "-json", "-run", "^TestA$/^y$", "./foo"},
+			},
+		},
+		{
+			name: "subtests of a marked test",
+			targets: []*tip.Target{
+				tip.NewTarget("./foo/foo_test.go", "TestA/x", tip.TestKindTest, false),
+				tip.NewTarget("./foo/foo_test.go", "TestA", tip.TestKindTest, false),
+				tip.NewTarget("./foo/foo_test.go", "TestB/y", tip.TestKindTest, false),
+			},
+			want: [][]string{
+				{"go", "test", "-json", "-run", "^TestA$", "./foo"},
+				{"go", "test", "-json", "-run", "^TestB$/^y$", "./foo"},
+			},
+		},
+		{
+			name: "prefix targets",
+			targets: []*tip.Target{
+				tip.NewTarget("./foo/foo_test.go", "TestA/???", tip.TestKindTest, true),
+				tip.NewTarget("./foo/foo_test.go", "TestB", tip.TestKindTest, false),
+				tip.NewTarget("./foo/foo_test.go", "TestA/x", tip.TestKindTest, false),
+			},
+			want: [][]string{
+				{"go", "test", "-json", "-run", "^(TestA|TestB)$", "./foo"},
+			},
+		},
+		{
+			name: "packages with the same pattern",
+			targets: []*tip.Target{
+				tip.NewTarget("./foo/foo_test.go", "TestA", tip.TestKindTest, false),
+				tip.NewTarget("./bar/bar_test.go", "TestB", tip.TestKindTest, false),
+				tip.NewTarget("./baz/baz_test.go", "TestA", tip.TestKindTest, false),
+			},
+			want: [][]string{
+				{"go", "test", "-json", "-run", "^TestA$", "./foo", "./baz"},
+				{"go", "test", "-json", "-run", "^TestB$", "./bar"},
+			},
+		},
+		{
+			name: "tests and benchmarks",
+			targets: []*tip.Target{
+				tip.NewTarget("./foo/foo_test.go", "TestA", tip.TestKindTest, false),
+				tip.NewTarget("./foo/foo_test.go", "BenchmarkA", tip.TestKindBenchmark, false),
+				tip.NewTarget("./foo/foo_test.go", "ExampleA", tip.TestKindExample, true),
+			},
+			want: [][]string{
+				{"go", "test", "-json", "-run", "^(TestA|ExampleA.*)$", "./foo"},
+				{"go", "test", "-json", "-run", "^$", "-bench", "^BenchmarkA$", "./foo"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf := &tip.Config{}
+			runs := planTestRuns(tt.targets)
+			if len(runs) != len(tt.want) {
+				t.Fatalf("runs len = %d, want %d", len(runs), len(tt.want))
+			}
+			for i, run := range runs {
+				cmd := buildTestExecCommand(context.Background(), run, nil, conf)
+				if !slices.Equal(cmd.Args, tt.want[i]) {
+					t.Errorf("args[%d] = %q, want %q", i, cmd.Args, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestTargetsToTestRunRegex_quotesMetacharacters(t *testing.T) {
+	targets := []*tip.Target{
+		tip.NewTarget("./foo/foo_test.go", "TestA/a+b", tip.TestKindTest, false),
+		tip.NewTarget("./foo/foo_test.go", "TestA/f(x)", tip.TestKindTest, false),
+	}
+	if got, want := targetsToTestRunRegex(targets), `^TestA$/^(a\+b|f\(x\))$`; got != want {
+		t.Errorf("regex = %q, want %q", got, want)
+	}
+	if got, want := targetsToTestRunRegex(targets[:1]), `^TestA$/^a\+b$`; got != want {
+		t.Errorf("regex = %q, want %q", got, want)
+	}
+}
+
+// TestTestRunRegex_roundTrip runs go test on testdata/regex to check that the generated pattern selects exactly the targets.
+func TestTestRunRegex_roundTrip(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs go test")
+	}
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go command not found")
+	}
+	const pkg = "./testdata/regex"
+
+	// -list shows top-level tests only
+	t.Run("list", func(t *testing.T) {
+		regex := targetsToTestRunRegex([]*tip.Target{tip.NewTarget(pkg+"/regex_test.go", "TestList", tip.TestKindTest, false)})
+		out, err := exec.Command("go", "test", "-list", regex, pkg).Output()
+		if err != nil {
+			t.Fatalf("go test -list: %v", err)
+		}
+		var got []string
+		for _, line := range strings.Split(string(out), "\n") {
+			if strings.HasPrefix(line, "Test") {
+				got = append(got, line)
+			}
+		}
+		if want := []string{"TestList"}; !slices.Equal(got, want) {
+			t.Errorf("listed = %q, want %q", got, want)
+		}
+	})
+
+	tests := []struct {
+		names []string
+		want  []string // including the parents, which run to reach the subtests
+	}{
+		{names: []string{"TestMeta/a+b"}, want: []string{"TestMeta", "TestMeta/a+b"}},
+		{names: []string{"TestMeta/f(x)"}, want: []string{"TestMeta", "TestMeta/f(x)"}},
+		{names: []string{"TestMeta/x.y"}, want: []string{"TestMeta", "TestMeta/x.y"}},
+		{names: []string{"TestMeta/[set]"}, want: []string{"TestMeta", "TestMeta/[set]"}},
+		{names: []string{"TestMeta/a|b"}, want: []string{"TestMeta", "TestMeta/a|b"}},
+		{names: []string{"TestMeta/star*"}, want: []string{"TestMeta", "TestMeta/star*"}},
+		{names: []string{"TestMeta/$dollar^"}, want: []string{"TestMeta", "TestMeta/$dollar^"}},
+		{names: []string{`TestMeta/back\slash`}, want: []string{"TestMeta", `TestMeta/back\slash`}},
+		{names: []string{"TestMeta/q?"}, want: []string{"TestMeta", "TestMeta/q?"}},
+		{names: []string{"TestMeta/{1}"}, want: []string{"TestMeta", "TestMeta/{1}"}},
+		{names: []string{"TestNested/g(1)/a.b"}, want: []string{"TestNested", "TestNested/g(1)", "TestNested/g(1)/a.b"}},
+		{
+			names: []string{"TestMeta/a+b", "TestMeta/a|b", "TestMeta/f(x)"},
+			want:  []string{"TestMeta", "TestMeta/a+b", "TestMeta/f(x)", "TestMeta/a|b"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.names, ","), func(t *testing.T) {
+			targets := make([]*tip.Target, 0, len(tt.names))
+			for _, name := range tt.names {
+				targets = append(targets, tip.NewTarget(pkg+"/regex_test.go", name, tip.TestKindTest, false))
+			}
+			regex := targetsToTestRunRegex(targets)
+			if got := runTestNames(t, regex, pkg); !slices.Equal(got, tt.want) {
+				t.Errorf("-run %q ran %q, want %q", regex, got, tt.want)
+			}
+		})
+	}
+}
+
+// runTestNames returns the names of the tests run by go test -run regex, in the order they started.
+func runTestNames(t *testing.T, regex, pkg string) []string {
+	t.Helper()
+	out, err := exec.Command("go", "test", "-json", "-run", regex, pkg).Output()
+	if err != nil {
+		t.Fatalf("go test -run %q: %v", regex, err)
+	}
+	names := make([]string, 0)
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		var e testEvent
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			t.Fatalf("invalid event %q: %v", line, err)
+		}
+		if e.Action == "run" {
+			names = append(names, e.Test)
+		}
+	}
+	return names
+}
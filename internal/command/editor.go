@@ -0,0 +1,52 @@
+package command
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/lusingander/gotip/internal/tip"
+)
+
+const (
+	editorFileMarker   = "${file}"
+	editorLineMarker   = "${line}"
+	editorColumnMarker = "${column}"
+)
+
+var ErrEditorNotConfigured = errors.New("no editor configured: set $VISUAL or $EDITOR, or editor in gotip.toml")
+
+// Editor returns a command that opens the file at the given position.
+// If no editor is configured, $VISUAL or $EDITOR is used with the `+line file` arguments most editors accept.
+func Editor(pos tip.Position, conf *tip.Config) (*exec.Cmd, error) {
+	command := conf.Editor
+	if len(command) == 0 {
+		command = defaultEditorCommand()
+	}
+	if len(command) == 0 {
+		return nil, ErrEditorNotConfigured
+	}
+
+	replacer := strings.NewReplacer(
+		editorFileMarker, pos.File,
+		editorLineMarker, strconv.Itoa(max(pos.Line, 1)),
+		editorColumnMarker, strconv.Itoa(max(pos.Column, 1)),
+	)
+	args := make([]string, 0, len(command)-1)
+	for _, arg := range command[1:] {
+		args = append(args, replacer.Replace(arg))
+	}
+	return exec.Command(command[0], args...), nil
+}
+
+func defaultEditorCommand() []string {
+	for _, env := range []string{"VISUAL", "EDITOR"} {
+		// the variable may contain arguments, such as "code --wait"
+		if fields := strings.Fields(os.Getenv(env)); len(fields) > 0 {
+			return append(fields, "+"+editorLineMarker, editorFileMarker)
+		}
+	}
+	return nil
+}
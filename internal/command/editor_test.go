@@ -0,0 +1,60 @@
+package command
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/lusingander/gotip/internal/tip"
+)
+
+func TestEditor(t *testing.T) {
+	pos := tip.Position{File: "./foo/foo_test.go", Line: 12, Column: 3}
+
+	tests := []struct {
+		name   string
+		editor []string
+		visual string
+		env    string
+		want   []string
+	}{
+		{
+			name:   "configured command",
+			editor: []string{"code", "--goto", "${file}:${line}:${column}"},
+			env:    "vim",
+			want:   []string{"code", "--goto", "./foo/foo_test.go:12:3"},
+		},
+		{
+			name:   "visual takes precedence over editor",
+			visual: "nvim",
+			env:    "vim",
+			want:   []string{"nvim", "+12", "./foo/foo_test.go"},
+		},
+		{
+			name: "editor with arguments",
+			env:  "emacs -nw",
+			want: []string{"emacs", "-nw", "+12", "./foo/foo_test.go"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("VISUAL", tt.visual)
+			t.Setenv("EDITOR", tt.env)
+			cmd, err := Editor(pos, &tip.Config{Editor: tt.editor})
+			if err != nil {
+				t.Fatalf("Editor() error = %v", err)
+			}
+			if !slices.Equal(cmd.Args, tt.want) {
+				t.Errorf("args = %q, want %q", cmd.Args, tt.want)
+			}
+		})
+	}
+}
+
+func TestEditor_notConfigured(t *testing.T) {
+	t.Setenv("VISUAL", "")
+	t.Setenv("EDITOR", "")
+	if _, err := Editor(tip.Position{File: "a_test.go", Line: 1}, &tip.Config{}); err != ErrEditorNotConfigured {
+		t.Errorf("Editor() error = %v, want %v", err, ErrEditorNotConfigured)
+	}
+}
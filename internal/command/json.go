@@ -0,0 +1,205 @@
+package command
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/lusingander/gotip/internal/tip"
+)
+
+var (
+	summaryPassStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#00A29C"))
+	summaryFailStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#CE3262"))
+	summarySkipStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#777777"))
+)
+
+// testEvent is an event emitted by go test -json.
+// See `go doc cmd/test2json` for details.
+type testEvent struct {
+	Time    time.Time
+	Action  string
+	Package string
+	Test    string
+	Elapsed float64 // seconds
+	Output  string
+}
+
+// testEventWriter parses the go test -json stream written to it, writes its output in a human-readable form,
+// and collects the results of each test. Lines which are not test events are written as they are.
+type testEventWriter struct {
+	w             io.Writer
+	verbose       bool
+	dirImportPath string
+	buf           []byte
+	outputs       map[string]*strings.Builder
+	result        *tip.RunResult
+}
+
+func newTestEventWriter(w io.Writer, verbose bool, dirImportPath string) *testEventWriter {
+	return &testEventWriter{
+		w:             w,
+		verbose:       verbose,
+		dirImportPath: dirImportPath,
+		outputs:       make(map[string]*strings.Builder),
+		result:        &tip.RunResult{},
+	}
+}
+
+func (w *testEventWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		line := w.buf[:i+1]
+		if err := w.handleLine(line); err != nil {
+			return 0, err
+		}
+		w.buf = w.buf[i+1:]
+	}
+	return len(p), nil
+}
+
+// Flush handles the last line if it is not terminated by a newline.
+func (w *testEventWriter) Flush() error {
+	if len(w.buf) == 0 {
+		return nil
+	}
+	line := w.buf
+	w.buf = nil
+	return w.handleLine(line)
+}
+
+func (w *testEventWriter) handleLine(line []byte) error {
+	var event testEvent
+	if !bytes.HasPrefix(line, []byte("{")) || json.Unmarshal(line, &event) != nil || event.Action == "" {
+		_, err := w.w.Write(line)
+		return err
+	}
+	return w.handleEvent(&event)
+}
+
+func (w *testEventWriter) handleEvent(event *testEvent) error {
+	pkg := tip.RelativePackageName(event.Package, w.dirImportPath)
+	key := pkg + " " + event.Test
+	elapsed := time.Duration(event.Elapsed * float64(time.Second))
+
+	switch event.Action {
+	case "output", "build-output":
+		if event.Test == "" {
+			return w.writePackageOutput(event.Output)
+		}
+		return w.writeTestOutput(key, event.Test, event.Output)
+	case "pass", "fail", "skip":
+		status := testStatusFromAction(event.Action)
+		if event.Test == "" {
+			w.result.Packages = append(w.result.Packages, &tip.PackageResult{
+				Package: pkg,
+				Status:  status,
+				Elapsed: elapsed,
+			})
+			return nil
+		}
+		var output string
+		if b, ok := w.outputs[key]; ok {
+			output = b.String()
+			delete(w.outputs, key)
+		}
+		w.result.Tests = append(w.result.Tests, &tip.TestResult{
+			Package: pkg,
+			Name:    event.Test,
+			Status:  status,
+			Elapsed: elapsed,
+			Output:  output,
+		})
+		if status == tip.TestStatusFail && !w.streams(event.Test) {
+			// the output of failed tests is always shown, as go test does without -v
+			_, err := io.WriteString(w.w, output)
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *testEventWriter) writePackageOutput(output string) error {
+	if !w.verbose && output == "PASS\n" {
+		// go test without -v only prints the "ok" line for passed packages
+		return nil
+	}
+	_, err := io.WriteString(w.w, output)
+	return err
+}
+
+func (w *testEventWriter) writeTestOutput(key, test, output string) error {
+	if strings.HasPrefix(output, "=== ") && !w.verbose {
+		// framing lines such as "=== RUN" are only printed with -v
+		return nil
+	}
+	b, ok := w.outputs[key]
+	if !ok {
+		b = &strings.Builder{}
+		w.outputs[key] = b
+	}
+	b.WriteString(output)
+	if w.streams(test) {
+		_, err := io.WriteString(w.w, output)
+		return err
+	}
+	return nil
+}
+
+// streams reports whether the output of the test is written as soon as it arrives.
+// Benchmark results and fuzzing progress have to be shown even though they pass.
+func (w *testEventWriter) streams(test string) bool {
+	return w.verbose || strings.HasPrefix(test, "Benchmark") || strings.HasPrefix(test, "Fuzz")
+}
+
+func testStatusFromAction(action string) tip.TestStatus {
+	switch action {
+	case "fail":
+		return tip.TestStatusFail
+	case "skip":
+		return tip.TestStatusSkip
+	default:
+		return tip.TestStatusPass
+	}
+}
+
+func isVerbose(args []string) bool {
+	for _, arg := range args {
+		switch arg {
+		case "-v", "-v=true", "--v", "-test.v", "-test.v=true":
+			return true
+		}
+	}
+	return false
+}
+
+func writeSummary(w io.Writer, result *tip.RunResult) error {
+	if len(result.Tests) == 0 && len(result.Packages) == 0 {
+		// the command did not emit test events
+		return nil
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "\n%s %s, %s, %s\n",
+		outputStyle.Render("Summary:"),
+		summaryPassStyle.Render(fmt.Sprintf("%d passed", result.Count(tip.TestStatusPass))),
+		summaryFailStyle.Render(fmt.Sprintf("%d failed", result.Count(tip.TestStatusFail))),
+		summarySkipStyle.Render(fmt.Sprintf("%d skipped", result.Count(tip.TestStatusSkip))),
+	)
+	for _, pkg := range result.FailedPackages() {
+		fmt.Fprintf(&b, "%s %s\n", summaryFailStyle.Render("FAIL"), pkg.Package)
+	}
+	for _, test := range result.FailedLeaves() {
+		fmt.Fprintf(&b, "%s %s %s (%.2fs)\n", summaryFailStyle.Render("FAIL"), test.Package, test.Name, test.Elapsed.Seconds())
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,118 @@
+package command
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/lusingander/gotip/internal/tip"
+)
+
+const testEventsFixture = `{"Action":"start","Package":"example.com/m/foo"}
+{"Action":"run","Package":"example.com/m/foo","Test":"TestA"}
+{"Action":"output","Package":"example.com/m/foo","Test":"TestA","Output":"=== RUN   TestA\n"}
+{"Action":"run","Package":"example.com/m/foo","Test":"TestA/x_y"}
+{"Action":"output","Package":"example.com/m/foo","Test":"TestA/x_y","Output":"=== RUN   TestA/x_y\n"}
+{"Action":"output","Package":"example.com/m/foo","Test":"TestA/x_y","Output":"    a_test.go:3: bad\n"}
+{"Action":"output","Package":"example.com/m/foo","Test":"TestA/x_y","Output":"--- FAIL: TestA/x_y (0.00s)\n"}
+{"Action":"fail","Package":"example.com/m/foo","Test":"TestA/x_y","Elapsed":0.01}
+{"Action":"run","Package":"example.com/m/foo","Test":"TestA/ok"}
+{"Action":"output","Package":"example.com/m/foo","Test":"TestA/ok","Output":"=== RUN   TestA/ok\n"}
+{"Action":"output","Package":"example.com/m/foo","Test":"TestA/ok","Output":"--- PASS: TestA/ok (0.00s)\n"}
+{"Action":"pass","Package":"example.com/m/foo","Test":"TestA/ok","Elapsed":0}
+{"Action":"output","Package":"example.com/m/foo","Test":"TestA","Output":"--- FAIL: TestA (0.00s)\n"}
+{"Action":"fail","Package":"example.com/m/foo","Test":"TestA","Elapsed":0.01}
+{"Action":"run","Package":"example.com/m/foo","Test":"TestB"}
+{"Action":"output","Package":"example.com/m/foo","Test":"TestB","Output":"--- SKIP: TestB (0.00s)\n"}
+{"Action":"skip","Package":"example.com/m/foo","Test":"TestB","Elapsed":0}
+{"Action":"output","Package":"example.com/m/foo","Output":"FAIL\n"}
+{"Action":"output","Package":"example.com/m/foo","Output":"FAIL\texample.com/m/foo\t0.003s\n"}
+{"Action":"fail","Package":"example.com/m/foo","Elapsed":0.003}
+{"Action":"output","Package":"example.com/m","Output":"PASS\n"}
+{"Action":"output","Package":"example.com/m","Output":"ok  \texample.com/m\t0.002s\n"}
+{"Action":"pass","Package":"example.com/m","Elapsed":0.002}
+not a test event
+`
+
+func TestTestEventWriter(t *testing.T) {
+	var out strings.Builder
+	w := newTestEventWriter(&out, false, "example.com/m")
+	// write in small chunks to check that lines split across writes are handled
+	for i := 0; i < len(testEventsFixture); i += 7 {
+		chunk := testEventsFixture[i:min(i+7, len(testEventsFixture))]
+		if _, err := w.Write([]byte(chunk)); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	wantOutput := `    a_test.go:3: bad
+--- FAIL: TestA/x_y (0.00s)
+--- FAIL: TestA (0.00s)
+FAIL
+FAIL	example.com/m/foo	0.003s
+ok  	example.com/m	0.002s
+not a test event
+`
+	if out.String() != wantOutput {
+		t.Errorf("output = %q, want %q", out.String(), wantOutput)
+	}
+
+	result := w.result
+	if len(result.Tests) != 4 {
+		t.Fatalf("tests len = %d, want 4", len(result.Tests))
+	}
+	got := result.Tests[0]
+	if got.Package != "./foo" || got.Name != "TestA/x_y" || got.Status != tip.TestStatusFail {
+		t.Errorf("test = %+v, want failed ./foo TestA/x_y", got)
+	}
+	if got.Output != "    a_test.go:3: bad\n--- FAIL: TestA/x_y (0.00s)\n" {
+		t.Errorf("test output = %q", got.Output)
+	}
+	if len(result.Packages) != 2 || result.Packages[1].Package != "." {
+		t.Errorf("packages = %+v, want ./foo and .", result.Packages)
+	}
+}
+
+func TestTestEventWriter_verbose(t *testing.T) {
+	var out strings.Builder
+	w := newTestEventWriter(&out, true, "example.com/m")
+	if _, err := w.Write([]byte(testEventsFixture)); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	if !strings.Contains(out.String(), "=== RUN   TestA/ok\n--- PASS: TestA/ok (0.00s)\n") {
+		t.Errorf("output does not contain passed test output: %q", out.String())
+	}
+	if strings.Count(out.String(), "--- FAIL: TestA/x_y") != 1 {
+		t.Errorf("failed test output is not written exactly once: %q", out.String())
+	}
+}
+
+func TestWriteSummary(t *testing.T) {
+	result := &tip.RunResult{
+		Tests: []*tip.TestResult{
+			{Package: "./foo", Name: "TestA/x_y", Status: tip.TestStatusFail},
+			{Package: "./foo", Name: "TestA/ok", Status: tip.TestStatusPass},
+			{Package: "./foo", Name: "TestA", Status: tip.TestStatusFail},
+			{Package: "./foo", Name: "TestB", Status: tip.TestStatusSkip},
+		},
+		Packages: []*tip.PackageResult{
+			{Package: "./foo", Status: tip.TestStatusFail},
+			{Package: "./bar", Status: tip.TestStatusFail},
+		},
+	}
+	var out strings.Builder
+	if err := writeSummary(&out, result); err != nil {
+		t.Fatalf("writeSummary() error = %v", err)
+	}
+	want := `
+Summary: 1 passed, 2 failed, 1 skipped
+FAIL ./bar
+FAIL ./foo TestA/x_y (0.00s)
+`
+	if out.String() != want {
+		t.Errorf("summary = %q, want %q", out.String(), want)
+	}
+}
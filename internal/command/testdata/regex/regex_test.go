@@ -0,0 +1,34 @@
+package regex
+
+import "testing"
+
+func TestList(t *testing.T) {}
+
+func TestListAll(t *testing.T) {}
+
+func TestMeta(t *testing.T) {
+	for _, name := range []string{
+		"a+b", "ab", "aab",
+		"f(x)", "fx", "f",
+		"x.y", "xzy",
+		"[set]", "s",
+		"a|b", "a", "b",
+		"star*", "sta",
+		"$dollar^", "dollar",
+		`back\slash`,
+		"q?", "q",
+		"{1}",
+	} {
+		t.Run(name, func(t *testing.T) {})
+	}
+}
+
+func TestNested(t *testing.T) {
+	t.Run("g(1)", func(t *testing.T) {
+		t.Run("a.b", func(t *testing.T) {})
+		t.Run("acb", func(t *testing.T) {})
+	})
+	t.Run("g1", func(t *testing.T) {
+		t.Run("a.b", func(t *testing.T) {})
+	})
+}
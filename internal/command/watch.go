@@ -0,0 +1,131 @@
+package command
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/lusingander/gotip/internal/tip"
+	"github.com/lusingander/gotip/internal/watch"
+)
+
+var (
+	watchPassBannerStyle = lipgloss.NewStyle().Background(lipgloss.Color("#00A29C")).Foreground(lipgloss.Color("#ffffff")).Bold(true).Padding(0, 1)
+	watchFailBannerStyle = lipgloss.NewStyle().Background(lipgloss.Color("#CE3262")).Foreground(lipgloss.Color("#ffffff")).Bold(true).Padding(0, 1)
+	watchSeparatorStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("#777777"))
+)
+
+// WatchDirs returns the directories of the packages of the targets,
+// and of the packages in the main module they depend on, including dependencies of tests.
+// The dependencies are listed for the build configuration the tests are run with.
+func WatchDirs(targets []*tip.Target, build tip.BuildConfig) ([]string, error) {
+	packages := make([]string, 0)
+	for _, target := range targets {
+		if !slices.Contains(packages, target.PackageName) {
+			packages = append(packages, target.PackageName)
+		}
+	}
+
+	args := []string{"list", "-e", "-deps", "-test", "-f", "{{if and .Module .Module.Main}}{{.Dir}}{{end}}"}
+	args = append(args, build.BuildFlags()...)
+	cmd := exec.Command("go", append(args, packages...)...)
+	cmd.Env = build.Env()
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list packages to watch: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	dirs := make([]string, 0)
+	for _, dir := range strings.Split(string(out), "\n") {
+		if dir != "" && !slices.Contains(dirs, dir) {
+			dirs = append(dirs, dir)
+		}
+	}
+	if len(dirs) == 0 {
+		return nil, errors.New("no package directories to watch")
+	}
+	return dirs, nil
+}
+
+// Watch runs the targets, and runs them again whenever Go files in their packages or dependencies change,
+// until ctx is canceled. onRun is called with the result of each run.
+func Watch(ctx context.Context, targets []*tip.Target, extraArgs []string, conf *tip.Config, onRun func(*tip.RunResult) error) error {
+	dirs, err := WatchDirs(targets, conf.Build)
+	if err != nil {
+		return err
+	}
+	w, err := watch.New(dirs, watch.DefaultDebounce)
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	for {
+		result, err := runTest(ctx, targets, extraArgs, conf, os.Stdin, os.Stdout, os.Stderr)
+		if err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err := onRun(result); err != nil {
+			return err
+		}
+		if err := writeResultBanner(os.Stderr, result.ExitCode, time.Now()); err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case files, ok := <-w.Changes():
+			if !ok {
+				return nil
+			}
+			if err := writeWatchSeparator(os.Stderr, files); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func writeResultBanner(w io.Writer, exitCode int, at time.Time) error {
+	banner := watchPassBannerStyle.Render("PASS")
+	if exitCode != 0 {
+		banner = watchFailBannerStyle.Render("FAIL")
+	}
+	msg := watchSeparatorStyle.Render(fmt.Sprintf("%s  Watching for changes... (Ctrl-c to stop)", at.Format(time.TimeOnly)))
+	_, err := fmt.Fprintf(w, "\n%s %s\n", banner, msg)
+	return err
+}
+
+func writeWatchSeparator(w io.Writer, files []string) error {
+	_, err := fmt.Fprintf(w, "\n%s\n\n", watchSeparatorStyle.Render(WatchSeparator(files, 80)))
+	return err
+}
+
+// WatchSeparator returns a line to separate runs triggered by changes of the files.
+func WatchSeparator(files []string, width int) string {
+	wd, _ := os.Getwd()
+	names := make([]string, 0, len(files))
+	for _, f := range files {
+		// show paths relative to the project if possible
+		if rel, err := filepath.Rel(wd, f); err == nil && !strings.HasPrefix(rel, "..") {
+			f = rel
+		}
+		names = append(names, f)
+	}
+	label := fmt.Sprintf("── changed: %s ", strings.Join(names, ", "))
+	return label + strings.Repeat("─", max(width-lipgloss.Width(label), 0))
+}
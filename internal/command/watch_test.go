@@ -0,0 +1,44 @@
+package command
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/lusingander/gotip/internal/tip"
+)
+
+func TestWatchDirs(t *testing.T) {
+	targets := []*tip.Target{
+		{PackageName: ".", TestNamePattern: "TestWatchDirs"},
+	}
+	dirs, err := WatchDirs(targets, tip.BuildConfig{})
+	if err != nil {
+		t.Fatalf("WatchDirs() error = %v", err)
+	}
+
+	wd, err := filepath.Abs(".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tipDir := filepath.Join(filepath.Dir(wd), "tip")
+	for _, want := range []string{wd, tipDir} {
+		if !slices.Contains(dirs, want) {
+			t.Errorf("dirs = %q, want to contain %q", dirs, want)
+		}
+	}
+	for _, dir := range dirs {
+		if !strings.HasPrefix(dir, filepath.Dir(filepath.Dir(wd))) {
+			t.Errorf("dirs contains %q outside the module", dir)
+		}
+	}
+}
+
+func TestWatchSeparator(t *testing.T) {
+	got := WatchSeparator([]string{"a.go", "b_test.go"}, 30)
+	want := "── changed: a.go, b_test.go ──"
+	if got != want {
+		t.Errorf("WatchSeparator() = %q, want %q", got, want)
+	}
+}
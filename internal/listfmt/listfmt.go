@@ -16,19 +16,37 @@ type document struct {
 }
 
 type file struct {
-	Path  string `json:"path"`
-	Tests []test `json:"tests"`
+	Path        string       `json:"path"`
+	Tests       []test       `json:"tests"`
+	Diagnostics []diagnostic `json:"diagnostics,omitempty"`
 }
 
 type test struct {
-	Name     string    `json:"name"`
-	Subtests []subtest `json:"subtests"`
+	Name          string    `json:"name"`
+	Kind          string    `json:"kind"`
+	HasOutput     *bool     `json:"hasOutput,omitempty"`
+	FileHasErrors bool      `json:"fileHasErrors,omitempty"`
+	Position      *position `json:"position,omitempty"`
+	Subtests      []subtest `json:"subtests"`
 }
 
 type subtest struct {
-	Name     *string   `json:"name"`
-	Resolved bool      `json:"resolved"`
-	Subtests []subtest `json:"subtests"`
+	Name        *string   `json:"name"`
+	DisplayName string    `json:"displayName,omitempty"`
+	Resolved    bool      `json:"resolved"`
+	Position    *position `json:"position,omitempty"`
+	Subtests    []subtest `json:"subtests"`
+}
+
+type diagnostic struct {
+	Message  string    `json:"message"`
+	Position *position `json:"position,omitempty"`
+}
+
+type position struct {
+	File   string `json:"file"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
 }
 
 func WriteText(w io.Writer, tests map[string][]*tip.TestFunction) error {
@@ -43,7 +61,21 @@ func WriteText(w io.Writer, tests map[string][]*tip.TestFunction) error {
 			return err
 		}
 		for _, tf := range tests[path] {
-			if _, err := fmt.Fprintf(w, "- %s\n", tf.Name); err != nil {
+			labels := make([]string, 0)
+			if tf.Kind != tip.TestKindTest {
+				labels = append(labels, tf.Kind.String())
+			}
+			if tf.Kind == tip.TestKindExample && !tf.HasOutput {
+				labels = append(labels, "no output")
+			}
+			if tf.FileHasErrors {
+				labels = append(labels, "file has errors")
+			}
+			name := tf.Name
+			if len(labels) > 0 {
+				name += " [" + strings.Join(labels, ", ") + "]"
+			}
+			if _, err := fmt.Fprintf(w, "- %s\n", name); err != nil {
 				return err
 			}
 			if err := writeTextSubtests(w, tf.Subs, 1); err != nil {
@@ -70,8 +102,9 @@ func writeTextSubtests(w io.Writer, subs []*tip.SubTest, depth int) error {
 	return nil
 }
 
-func WriteJSON(w io.Writer, tests map[string][]*tip.TestFunction) error {
-	doc := newDocument(tests)
+// WriteJSON writes the tests with the diagnostics of the files, which are keyed by the file paths as the tests.
+func WriteJSON(w io.Writer, tests map[string][]*tip.TestFunction, diagnostics map[string][]tip.Diagnostic) error {
+	doc := newDocument(tests, diagnostics)
 	enc := json.NewEncoder(w)
 	enc.SetIndent("", "  ")
 	return enc.Encode(doc)
@@ -85,29 +118,39 @@ func FormatText(tests map[string][]*tip.TestFunction) (string, error) {
 	return buf.String(), nil
 }
 
-func FormatJSON(tests map[string][]*tip.TestFunction) (string, error) {
+func FormatJSON(tests map[string][]*tip.TestFunction, diagnostics map[string][]tip.Diagnostic) (string, error) {
 	var buf bytes.Buffer
-	if err := WriteJSON(&buf, tests); err != nil {
+	if err := WriteJSON(&buf, tests, diagnostics); err != nil {
 		return "", err
 	}
 	return buf.String(), nil
 }
 
-func newDocument(tests map[string][]*tip.TestFunction) document {
+func newDocument(tests map[string][]*tip.TestFunction, diagnostics map[string][]tip.Diagnostic) document {
 	paths := sortedPaths(tests)
 	files := make([]file, 0, len(paths))
 	for _, path := range paths {
 		functions := tests[path]
 		outTests := make([]test, 0, len(functions))
 		for _, tf := range functions {
+			var hasOutput *bool
+			if tf.Kind == tip.TestKindExample {
+				value := tf.HasOutput
+				hasOutput = &value
+			}
 			outTests = append(outTests, test{
-				Name:     tf.Name,
-				Subtests: newSubtests(tf.Subs),
+				Name:          tf.Name,
+				Kind:          tf.Kind.String(),
+				HasOutput:     hasOutput,
+				FileHasErrors: tf.FileHasErrors,
+				Position:      newPosition(tf.Pos),
+				Subtests:      newSubtests(tf.Subs),
 			})
 		}
 		files = append(files, file{
-			Path:  path,
-			Tests: outTests,
+			Path:        path,
+			Tests:       outTests,
+			Diagnostics: newDiagnostics(diagnostics[path]),
 		})
 	}
 	return document{Files: files}
@@ -122,14 +165,41 @@ func newSubtests(subs []*tip.SubTest) []subtest {
 			name = &value
 		}
 		out = append(out, subtest{
-			Name:     name,
-			Resolved: sub.Resolved,
-			Subtests: newSubtests(sub.Subs),
+			Name:        name,
+			DisplayName: sub.DisplayName,
+			Resolved:    sub.Resolved,
+			Position:    newPosition(sub.Pos),
+			Subtests:    newSubtests(sub.Subs),
 		})
 	}
 	return out
 }
 
+func newDiagnostics(diagnostics []tip.Diagnostic) []diagnostic {
+	if len(diagnostics) == 0 {
+		return nil
+	}
+	out := make([]diagnostic, 0, len(diagnostics))
+	for _, d := range diagnostics {
+		out = append(out, diagnostic{
+			Message:  d.Message,
+			Position: newPosition(d.Pos),
+		})
+	}
+	return out
+}
+
+func newPosition(pos tip.Position) *position {
+	if !pos.IsValid() {
+		return nil
+	}
+	return &position{
+		File:   pos.File,
+		Line:   pos.Line,
+		Column: pos.Column,
+	}
+}
+
 func sortedPaths(tests map[string][]*tip.TestFunction) []string {
 	paths := make([]string, 0, len(tests))
 	for path := range tests {
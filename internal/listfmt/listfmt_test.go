@@ -20,7 +20,11 @@ func TestFormatText(t *testing.T) {
 # ./b/b_test.go
 - TestB
   - outer
-    - inner
+    - inner_case
+- BenchmarkB [benchmark]
+  - small
+- ExampleB [example]
+- ExampleB_noOutput [example, no output]
 `
 	if got != want {
 		t.Errorf("FormatText() = %q, want %q", got, want)
@@ -29,7 +33,7 @@ func TestFormatText(t *testing.T) {
 
 func TestFormatJSON(t *testing.T) {
 	tests := fixtureTests()
-	got, err := FormatJSON(tests)
+	got, err := FormatJSON(tests, nil)
 	if err != nil {
 		t.Fatalf("FormatJSON() error = %v", err)
 	}
@@ -40,15 +44,31 @@ func TestFormatJSON(t *testing.T) {
       "tests": [
         {
           "name": "TestA",
+          "kind": "test",
+          "position": {
+            "file": "./a/a_test.go",
+            "line": 5,
+            "column": 1
+          },
           "subtests": [
             {
               "name": "alpha",
               "resolved": true,
+              "position": {
+                "file": "./a/a_test.go",
+                "line": 8,
+                "column": 3
+              },
               "subtests": []
             },
             {
               "name": null,
               "resolved": false,
+              "position": {
+                "file": "./a/a_test.go",
+                "line": 12,
+                "column": 2
+              },
               "subtests": []
             }
           ]
@@ -60,19 +80,44 @@ func TestFormatJSON(t *testing.T) {
       "tests": [
         {
           "name": "TestB",
+          "kind": "test",
           "subtests": [
             {
               "name": "outer",
               "resolved": true,
               "subtests": [
                 {
-                  "name": "inner",
+                  "name": "inner_case",
+                  "displayName": "inner case",
                   "resolved": true,
                   "subtests": []
                 }
               ]
             }
           ]
+        },
+        {
+          "name": "BenchmarkB",
+          "kind": "benchmark",
+          "subtests": [
+            {
+              "name": "small",
+              "resolved": true,
+              "subtests": []
+            }
+          ]
+        },
+        {
+          "name": "ExampleB",
+          "kind": "example",
+          "hasOutput": true,
+          "subtests": []
+        },
+        {
+          "name": "ExampleB_noOutput",
+          "kind": "example",
+          "hasOutput": false,
+          "subtests": []
         }
       ]
     }
@@ -84,6 +129,79 @@ func TestFormatJSON(t *testing.T) {
 	}
 }
 
+func TestFormatText_fileHasErrors(t *testing.T) {
+	tests, _ := fixtureBrokenTests()
+	got, err := FormatText(tests)
+	if err != nil {
+		t.Fatalf("FormatText() error = %v", err)
+	}
+	want := `# ./c/c_test.go
+- TestC [file has errors]
+- BenchmarkC [benchmark, file has errors]
+`
+	if got != want {
+		t.Errorf("FormatText() = %q, want %q", got, want)
+	}
+}
+
+func TestFormatJSON_diagnostics(t *testing.T) {
+	tests, diagnostics := fixtureBrokenTests()
+	got, err := FormatJSON(tests, diagnostics)
+	if err != nil {
+		t.Fatalf("FormatJSON() error = %v", err)
+	}
+	want := `{
+  "files": [
+    {
+      "path": "./c/c_test.go",
+      "tests": [
+        {
+          "name": "TestC",
+          "kind": "test",
+          "fileHasErrors": true,
+          "subtests": []
+        },
+        {
+          "name": "BenchmarkC",
+          "kind": "benchmark",
+          "fileHasErrors": true,
+          "subtests": []
+        }
+      ],
+      "diagnostics": [
+        {
+          "message": "expected ')', found newline",
+          "position": {
+            "file": "./c/c_test.go",
+            "line": 9,
+            "column": 16
+          }
+        }
+      ]
+    }
+  ]
+}
+`
+	if got != want {
+		t.Errorf("FormatJSON() = %q, want %q", got, want)
+	}
+}
+
+func fixtureBrokenTests() (map[string][]*tip.TestFunction, map[string][]tip.Diagnostic) {
+	tests := map[string][]*tip.TestFunction{
+		"./c/c_test.go": {
+			{Name: "TestC", Subs: []*tip.SubTest{}, FileHasErrors: true},
+			{Name: "BenchmarkC", Kind: tip.TestKindBenchmark, Subs: []*tip.SubTest{}, FileHasErrors: true},
+		},
+	}
+	diagnostics := map[string][]tip.Diagnostic{
+		"./c/c_test.go": {
+			{Pos: tip.Position{File: "./c/c_test.go", Line: 9, Column: 16}, Message: "expected ')', found newline"},
+		},
+	}
+	return tests, diagnostics
+}
+
 func fixtureTests() map[string][]*tip.TestFunction {
 	return map[string][]*tip.TestFunction{
 		"./b/b_test.go": {
@@ -94,18 +212,37 @@ func fixtureTests() map[string][]*tip.TestFunction {
 						Name:     "outer",
 						Resolved: true,
 						Subs: []*tip.SubTest{
-							{Name: "inner", Resolved: true, Subs: []*tip.SubTest{}},
+							{Name: "inner_case", DisplayName: "inner case", Resolved: true, Subs: []*tip.SubTest{}},
 						},
 					},
 				},
 			},
+			{
+				Name: "BenchmarkB",
+				Kind: tip.TestKindBenchmark,
+				Subs: []*tip.SubTest{
+					{Name: "small", Resolved: true, Subs: []*tip.SubTest{}},
+				},
+			},
+			{
+				Name:      "ExampleB",
+				Kind:      tip.TestKindExample,
+				Subs:      []*tip.SubTest{},
+				HasOutput: true,
+			},
+			{
+				Name: "ExampleB_noOutput",
+				Kind: tip.TestKindExample,
+				Subs: []*tip.SubTest{},
+			},
 		},
 		"./a/a_test.go": {
 			{
 				Name: "TestA",
+				Pos:  tip.Position{File: "./a/a_test.go", Line: 5, Column: 1},
 				Subs: []*tip.SubTest{
-					{Name: "alpha", Resolved: true, Subs: []*tip.SubTest{}},
-					{Name: "", Resolved: false, Subs: []*tip.SubTest{}},
+					{Name: "alpha", Resolved: true, Subs: []*tip.SubTest{}, Pos: tip.Position{File: "./a/a_test.go", Line: 8, Column: 3}},
+					{Name: "", Resolved: false, Subs: []*tip.SubTest{}, Pos: tip.Position{File: "./a/a_test.go", Line: 12, Column: 2}},
 				},
 			},
 		},
@@ -0,0 +1,126 @@
+package parse
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"go/build"
+	"io"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/lusingander/gotip/internal/tip"
+)
+
+// discoveryCache looks up and records the tests of each file in the cache.
+// A nil *discoveryCache disables caching.
+type discoveryCache struct {
+	cache *tip.DiscoveryCache
+	// options the tests are discovered with, which are a part of the keys
+	options string
+
+	mu sync.Mutex
+	// entries of the files discovered this time, which replace the cached ones so that removed files are dropped
+	entries map[string]*tip.DiscoveryCacheEntry
+}
+
+func newDiscoveryCache(cache *tip.DiscoveryCache, skipSubtests bool, ctxt *build.Context) *discoveryCache {
+	if cache == nil {
+		return nil
+	}
+	options := fmt.Sprintf("%s %t %s/%s cgo=%t tags=%s tool=%s release=%s",
+		tip.AppVersion, skipSubtests, ctxt.GOOS, ctxt.GOARCH, ctxt.CgoEnabled,
+		strings.Join(ctxt.BuildTags, ","), strings.Join(ctxt.ToolTags, ","), strings.Join(ctxt.ReleaseTags, ","))
+	return &discoveryCache{
+		cache:   cache,
+		options: options,
+		entries: make(map[string]*tip.DiscoveryCacheEntry),
+	}
+}
+
+// packageKey returns the key of the tests of the files in the directory.
+// Tests may refer to declarations in any file of the package, so the key changes if any Go file in the directory is added, removed, or modified.
+// The seed corpus files of fuzz tests are listed as subtests, so the key also changes if any of them is added, removed, or modified.
+func (c *discoveryCache) packageKey(dir string) (string, bool) {
+	if c == nil {
+		return "", false
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", false
+	}
+	h := sha256.New()
+	fmt.Fprintln(h, c.options)
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".go") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return "", false
+		}
+		fmt.Fprintln(h, entry.Name(), info.Size(), info.ModTime().UnixNano())
+	}
+	if err := hashFuzzCorpus(h, filepath.Join(dir, "testdata", "fuzz")); err != nil {
+		return "", false
+	}
+	return hex.EncodeToString(h.Sum(nil)), true
+}
+
+// hashFuzzCorpus writes the names and modification times of the files in testdata/fuzz/FuzzXxx directories.
+func hashFuzzCorpus(w io.Writer, fuzzDir string) error {
+	corpusDirs, err := os.ReadDir(fuzzDir)
+	if err != nil {
+		// no seed corpus
+		return nil
+	}
+	for _, corpusDir := range corpusDirs {
+		if !corpusDir.IsDir() {
+			continue
+		}
+		entries, err := os.ReadDir(filepath.Join(fuzzDir, corpusDir.Name()))
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			info, err := entry.Info()
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(w, path.Join("testdata/fuzz", corpusDir.Name(), entry.Name()), info.ModTime().UnixNano())
+		}
+	}
+	return nil
+}
+
+func (c *discoveryCache) get(path, key string) ([]*tip.TestFunction, bool) {
+	entry, ok := c.cache.Files[filepath.Clean(path)]
+	if !ok || entry.Key != key {
+		return nil, false
+	}
+	c.put(path, key, entry.Tests)
+	return entry.Tests, true
+}
+
+func (c *discoveryCache) put(path, key string, tests []*tip.TestFunction) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[filepath.Clean(path)] = &tip.DiscoveryCacheEntry{Key: key, Tests: tests}
+}
+
+// flush replaces the files of the cache with the ones discovered this time.
+// If the discovery is not complete, the cached files which are not discovered this time are kept instead.
+func (c *discoveryCache) flush(complete bool) {
+	if c == nil {
+		return
+	}
+	if !complete {
+		maps.Copy(c.cache.Files, c.entries)
+		return
+	}
+	c.cache.Files = c.entries
+}
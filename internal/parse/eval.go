@@ -0,0 +1,412 @@
+package parse
+
+import (
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"strconv"
+)
+
+// exprSubTestName is a name computed from an expression,
+// such as fmt.Sprintf("%s_%d", tt.name, tt.n) or "case-"+tt.name.
+type exprSubTestName struct {
+	cases []testCaseName
+}
+
+func (e *exprSubTestName) resolveTestName() ([]testCaseName, bool) {
+	if len(e.cases) == 0 {
+		return []testCaseName{{}}, false
+	}
+	return e.cases, true
+}
+
+// findSubTestNameFromExpr evaluates the expression for each entry of the table the enclosing range loop iterates over,
+// or once if it does not refer to the loop variables.
+// The name is resolved only if the expression can be evaluated for all entries.
+func findSubTestNameFromExpr(expr ast.Expr, scope *packageScope, cs ...subTestContext) *exprSubTestName {
+	n := &exprSubTestName{}
+	for i := len(cs) - 1; i >= 0; i-- {
+		rangeCtx, ok := cs[i].(*forRangeContext)
+		if !ok || !refersToRangeVariables(expr, rangeCtx) {
+			continue
+		}
+		cases := make([]testCaseName, 0)
+		for _, entry := range findTableEntries(rangeCtx, cs[:i]...) {
+			e := &evaluator{scope: scope, cs: cs, entry: entry}
+			name, ok := e.evalString(expr)
+			if !ok {
+				return n
+			}
+			cases = append(cases, testCaseName{name: name, pos: entry.pos})
+		}
+		n.cases = cases
+		return n
+	}
+
+	e := &evaluator{scope: scope, cs: cs}
+	if name, ok := e.evalString(expr); ok {
+		n.cases = []testCaseName{{name: name}}
+	}
+	return n
+}
+
+func refersToRangeVariables(expr ast.Expr, c *forRangeContext) bool {
+	found := false
+	ast.Inspect(expr, func(node ast.Node) bool {
+		if ident, ok := node.(*ast.Ident); ok && ident.Name != "_" && (ident.Name == c.keyIdent || ident.Name == c.valueIdent) {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+// typedExpr is an expression with its declared type.
+// A nil expr is the zero value of the type, such as a field omitted from a keyed struct literal.
+type typedExpr struct {
+	expr ast.Expr
+	typ  ast.Expr
+}
+
+// tableEntry is a single test case of a table, bound to the variables of the range loop.
+type tableEntry struct {
+	pos        token.Pos
+	idents     map[string]typedExpr
+	valueIdent string
+	fields     map[string]typedExpr // fields of the value, if it is a struct
+}
+
+func findTableEntries(rangeCtx *forRangeContext, cs ...subTestContext) []*tableEntry {
+	for i := len(cs) - 1; i >= 0; i-- {
+		switch c := cs[i].(type) {
+		case *structSliceLiteralDeclarationContext:
+			if c.ident == rangeCtx.iterIdent {
+				return c.tableEntries(rangeCtx, cs...)
+			}
+		case *mapLiteralDeclarationContext:
+			if c.ident == rangeCtx.iterIdent {
+				return c.tableEntries(rangeCtx, cs...)
+			}
+		}
+	}
+	return nil
+}
+
+func (c *structSliceLiteralDeclarationContext) tableEntries(rangeCtx *forRangeContext, cs ...subTestContext) []*tableEntry {
+	elemType := c.compLit.Type.(*ast.ArrayType).Elt
+	entries := make([]*tableEntry, 0, len(c.compLit.Elts))
+	for i, elt := range c.compLit.Elts {
+		index := &ast.BasicLit{Kind: token.INT, Value: strconv.Itoa(i)}
+		entries = append(entries, newTableEntry(rangeCtx, elt.Pos(),
+			typedExpr{expr: index, typ: ast.NewIdent("int")},
+			typedExpr{expr: elt, typ: elemType},
+			cs...,
+		))
+	}
+	return entries
+}
+
+func (c *mapLiteralDeclarationContext) tableEntries(rangeCtx *forRangeContext, cs ...subTestContext) []*tableEntry {
+	mapType := c.compLit.Type.(*ast.MapType)
+	entries := make([]*tableEntry, 0, len(c.compLit.Elts))
+	for _, elt := range c.compLit.Elts {
+		kv, ok := elt.(*ast.KeyValueExpr)
+		if !ok {
+			continue
+		}
+		entries = append(entries, newTableEntry(rangeCtx, kv.Pos(),
+			typedExpr{expr: kv.Key, typ: mapType.Key},
+			typedExpr{expr: kv.Value, typ: mapType.Value},
+			cs...,
+		))
+	}
+	return entries
+}
+
+func newTableEntry(rangeCtx *forRangeContext, pos token.Pos, key, value typedExpr, cs ...subTestContext) *tableEntry {
+	entry := &tableEntry{
+		pos: pos,
+		idents: map[string]typedExpr{
+			rangeCtx.keyIdent:   key,
+			rangeCtx.valueIdent: value,
+		},
+		valueIdent: rangeCtx.valueIdent,
+	}
+	structType := resolveStructType(value.typ, cs...)
+	if structType == nil {
+		return entry
+	}
+	lit, ok := compositeLitOf(value.expr)
+	if !ok {
+		return entry
+	}
+	entry.fields = structLiteralFields(structType, lit)
+	return entry
+}
+
+// structLiteralFields returns the values of all fields of the struct literal, including omitted ones.
+// If the struct type is unknown, only the keyed fields are returned.
+func structLiteralFields(structType *ast.StructType, lit *ast.CompositeLit) map[string]typedExpr {
+	fields := make(map[string]typedExpr)
+	names := make([]string, 0)
+	if structType != nil {
+		for _, field := range structType.Fields.List {
+			for _, name := range field.Names {
+				fields[name.Name] = typedExpr{typ: field.Type}
+				names = append(names, name.Name)
+			}
+		}
+	}
+	for i, elt := range lit.Elts {
+		name := ""
+		value := elt
+		if kv, ok := elt.(*ast.KeyValueExpr); ok {
+			if key, ok := kv.Key.(*ast.Ident); ok {
+				name = key.Name
+			}
+			value = kv.Value
+		} else if i < len(names) {
+			name = names[i]
+		}
+		if f, ok := fields[name]; ok || (structType == nil && name != "") {
+			fields[name] = typedExpr{expr: value, typ: f.typ}
+		}
+	}
+	return fields
+}
+
+// evaluator evaluates constant expressions whose operands are literals, string constants and the fields of a table entry.
+type evaluator struct {
+	scope *packageScope
+	cs    []subTestContext
+	entry *tableEntry // nil outside of a range loop over a table
+}
+
+func (e *evaluator) evalString(expr ast.Expr) (string, bool) {
+	v, _, ok := e.eval(expr)
+	if !ok || v.Kind() != constant.String {
+		return "", false
+	}
+	return constant.StringVal(v), true
+}
+
+// eval returns the value of the expression and the name of its type, which is empty if it is untyped.
+func (e *evaluator) eval(expr ast.Expr) (constant.Value, string, bool) {
+	if v, typ, ok := e.scope.constant(expr); ok {
+		// constants of any package, if the type information is loaded
+		return v, typ, true
+	}
+	switch x := expr.(type) {
+	case *ast.BasicLit:
+		v := constant.MakeFromLiteral(x.Value, x.Kind, 0)
+		return v, "", v.Kind() != constant.Unknown
+	case *ast.ParenExpr:
+		return e.eval(x.X)
+	case *ast.UnaryExpr:
+		v, typ, ok := e.eval(x.X)
+		if !ok || (x.Op != token.SUB && x.Op != token.ADD) || !isNumeric(v) {
+			return nil, "", false
+		}
+		return constant.UnaryOp(x.Op, v, 0), typ, true
+	case *ast.BinaryExpr:
+		return e.evalBinaryExpr(x)
+	case *ast.Ident:
+		return e.evalIdent(x)
+	case *ast.SelectorExpr:
+		if e.entry == nil || e.entry.fields == nil {
+			return nil, "", false
+		}
+		recv, ok := x.X.(*ast.Ident)
+		if !ok || recv.Name != e.entry.valueIdent {
+			return nil, "", false
+		}
+		field, ok := e.entry.fields[x.Sel.Name]
+		if !ok {
+			return nil, "", false
+		}
+		return e.evalTypedExpr(field)
+	case *ast.CallExpr:
+		return e.evalSprintf(x)
+	}
+	return nil, "", false
+}
+
+func (e *evaluator) evalBinaryExpr(x *ast.BinaryExpr) (constant.Value, string, bool) {
+	if x.Op != token.ADD {
+		return nil, "", false
+	}
+	lhs, lhsType, ok := e.eval(x.X)
+	if !ok {
+		return nil, "", false
+	}
+	rhs, rhsType, ok := e.eval(x.Y)
+	if !ok {
+		return nil, "", false
+	}
+	stringOperands := lhs.Kind() == constant.String && rhs.Kind() == constant.String
+	if !stringOperands && !(isNumeric(lhs) && isNumeric(rhs)) {
+		return nil, "", false
+	}
+	typ := lhsType
+	if typ == "" {
+		typ = rhsType
+	}
+	return constant.BinaryOp(lhs, token.ADD, rhs), typ, true
+}
+
+func (e *evaluator) evalIdent(ident *ast.Ident) (constant.Value, string, bool) {
+	if e.entry != nil && ident.Name != "_" {
+		if v, ok := e.entry.idents[ident.Name]; ok {
+			return e.evalTypedExpr(v)
+		}
+	}
+	for i := len(e.cs) - 1; i >= 0; i-- {
+		if c, ok := e.cs[i].(*stringIdentContext); ok && c.ident == ident.Name {
+			return constant.MakeString(c.value), "", true
+		}
+	}
+	switch ident.Name {
+	case "true", "false":
+		return constant.MakeBool(ident.Name == "true"), "", true
+	}
+	return nil, "", false
+}
+
+func (e *evaluator) evalTypedExpr(te typedExpr) (constant.Value, string, bool) {
+	typ := typeName(te.typ)
+	if te.expr == nil {
+		v, ok := zeroValue(typ)
+		return v, typ, ok
+	}
+	// table entries are evaluated without the loop variables
+	v, valueType, ok := (&evaluator{scope: e.scope, cs: e.cs}).eval(te.expr)
+	if !ok {
+		return nil, "", false
+	}
+	if typ == "" {
+		typ = valueType
+	}
+	return v, typ, true
+}
+
+// evalSprintf evaluates a fmt.Sprintf call, formatting the operands as the values of their types.
+func (e *evaluator) evalSprintf(call *ast.CallExpr) (constant.Value, string, bool) {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != "Sprintf" || len(call.Args) == 0 || call.Ellipsis.IsValid() {
+		return nil, "", false
+	}
+	if pkg, ok := sel.X.(*ast.Ident); !ok || pkg.Name != "fmt" {
+		return nil, "", false
+	}
+	format, ok := e.evalString(call.Args[0])
+	if !ok {
+		return nil, "", false
+	}
+	args := make([]any, 0, len(call.Args)-1)
+	for _, arg := range call.Args[1:] {
+		v, typ, ok := e.eval(arg)
+		if !ok {
+			return nil, "", false
+		}
+		value, ok := goValue(v, typ)
+		if !ok {
+			return nil, "", false
+		}
+		args = append(args, value)
+	}
+	return constant.MakeString(fmt.Sprintf(format, args...)), "string", true
+}
+
+func isNumeric(v constant.Value) bool {
+	return v.Kind() == constant.Int || v.Kind() == constant.Float
+}
+
+// typeName returns the name of a predeclared type, "any" for interfaces, or "?" for the other types,
+// whose values may be formatted differently (e.g. with a String method).
+func typeName(typ ast.Expr) string {
+	switch t := typ.(type) {
+	case nil:
+		return ""
+	case *ast.Ident:
+		return t.Name
+	case *ast.InterfaceType:
+		return "any"
+	}
+	return "?"
+}
+
+func zeroValue(typ string) (constant.Value, bool) {
+	switch {
+	case typ == "string":
+		return constant.MakeString(""), true
+	case typ == "bool":
+		return constant.MakeBool(false), true
+	case isIntType(typ) || isFloatType(typ):
+		return constant.MakeInt64(0), true
+	}
+	return nil, false
+}
+
+// goValue converts the constant to a Go value of its type, which is formatted the same way by fmt.
+func goValue(v constant.Value, typ string) (any, bool) {
+	switch {
+	case typ == "" || typ == "any":
+		switch v.Kind() {
+		case constant.String:
+			return constant.StringVal(v), true
+		case constant.Bool:
+			return constant.BoolVal(v), true
+		case constant.Int:
+			return constant.Int64Val(v)
+		case constant.Float:
+			f, _ := constant.Float64Val(v)
+			return f, true
+		}
+	case typ == "string":
+		if v.Kind() == constant.String {
+			return constant.StringVal(v), true
+		}
+	case typ == "bool":
+		if v.Kind() == constant.Bool {
+			return constant.BoolVal(v), true
+		}
+	case isIntType(typ):
+		i := constant.ToInt(v)
+		if i.Kind() != constant.Int {
+			return nil, false
+		}
+		if x, ok := constant.Int64Val(i); ok {
+			return x, true
+		}
+		return constant.Uint64Val(i)
+	case typ == "float32":
+		f := constant.ToFloat(v)
+		if f.Kind() != constant.Float {
+			return nil, false
+		}
+		f32, _ := constant.Float32Val(f)
+		return f32, true
+	case typ == "float64":
+		f := constant.ToFloat(v)
+		if f.Kind() != constant.Float {
+			return nil, false
+		}
+		f64, _ := constant.Float64Val(f)
+		return f64, true
+	}
+	return nil, false
+}
+
+func isIntType(typ string) bool {
+	switch typ {
+	case "int", "int8", "int16", "int32", "int64", "rune",
+		"uint", "uint8", "uint16", "uint32", "uint64", "uintptr", "byte":
+		return true
+	}
+	return false
+}
+
+func isFloatType(typ string) bool {
+	return typ == "float32" || typ == "float64"
+}
@@ -0,0 +1,96 @@
+package parse
+
+import (
+	"go/ast"
+	"go/token"
+	"slices"
+)
+
+// maxHelperDepth is the number of nested helper function calls followed to find subtests.
+const maxHelperDepth = 2
+
+// findSubTestsInHelperCall returns the subtests started by a same-package helper function that receives the *testing.T,
+// such as `runCases(t, cases)`, as subtests of the caller.
+// The parameters of the helper refer to the tables and strings the caller passes.
+func findSubTestsInHelperCall(call *ast.CallExpr, helper *ast.Ident, testingTReceivers []string, scope *packageScope, helperDepth int, cs ...subTestContext) []*unresolvedSubTest {
+	if helperDepth >= maxHelperDepth {
+		return nil
+	}
+	fn, ok := scope.funcs[helper.Name]
+	if !ok {
+		return nil
+	}
+	params, args := helperParams(fn, call)
+	if len(params) == 0 {
+		return nil
+	}
+
+	receivers := make([]string, 0)
+	for i, param := range params {
+		arg, ok := args[i].(*ast.Ident)
+		if ok && isTestingParamType(scope, param.typ) && slices.Contains(testingTReceivers, arg.Name) {
+			receivers = append(receivers, param.name)
+		}
+	}
+	if len(receivers) == 0 {
+		return nil
+	}
+
+	helperCs := append([]subTestContext{}, scope.contexts...)
+	e := &evaluator{scope: scope, cs: cs}
+	for i, param := range params {
+		// strings such as `runNamed(t, "name")`, or names the caller received as well
+		if v, ok := e.evalString(args[i]); ok {
+			helperCs = append(helperCs, &stringIdentContext{ident: param.name, value: v})
+		}
+		// tables such as `runCases(t, cases)` or `runCases(t, []testCase{...})`,
+		// which are bound to the parameter as if it were assigned at the beginning of the helper
+		assign := &ast.AssignStmt{Lhs: []ast.Expr{ast.NewIdent(param.name)}, Tok: token.DEFINE, Rhs: []ast.Expr{args[i]}}
+		helperCs = append(helperCs, findTableAliasesFromAssignStmt(assign, cs...)...)
+		if c := findStructSliceLiteralDeclarationFromAssignStmt(assign); c != nil {
+			helperCs = append(helperCs, c)
+		}
+		if c := findMapLiteralDeclarationFromAssignStmt(assign); c != nil {
+			helperCs = append(helperCs, c)
+		}
+	}
+	return findSubTests(fn.Body.List, receivers, scope, helperDepth+1, helperCs...)
+}
+
+type helperParam struct {
+	name string
+	typ  ast.Expr
+}
+
+// helperParams returns the named parameters of the helper with the arguments passed to them.
+func helperParams(fn *ast.FuncDecl, call *ast.CallExpr) ([]helperParam, []ast.Expr) {
+	if call.Ellipsis.IsValid() {
+		return nil, nil
+	}
+	params := make([]helperParam, 0)
+	args := make([]ast.Expr, 0)
+	i := 0
+	for _, field := range fn.Type.Params.List {
+		if _, ok := field.Type.(*ast.Ellipsis); ok {
+			// variadic arguments are not bound to a single parameter
+			break
+		}
+		names := field.Names
+		if len(names) == 0 {
+			// unnamed parameter
+			i++
+			continue
+		}
+		for _, name := range names {
+			if i >= len(call.Args) {
+				return nil, nil
+			}
+			if name.Name != "_" {
+				params = append(params, helperParam{name: name.Name, typ: field.Type})
+				args = append(args, call.Args[i])
+			}
+			i++
+		}
+	}
+	return params, args
+}
@@ -0,0 +1,118 @@
+package parse
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/lusingander/gotip/internal/tip"
+)
+
+// rewriteSubTestNames gives the subtests the names go test reports for them,
+// keeping the names written in the source as display names.
+// The subtests are siblings, and duplicate names get #NN suffixes in order.
+func rewriteSubTestNames(subs []*tip.SubTest) {
+	namer := newSubTestNamer()
+	for _, sub := range subs {
+		if !sub.Resolved {
+			// the name is unknown, so it cannot be counted as a duplicate
+			continue
+		}
+		name := namer.unique(rewriteSubTestName(sub.Name))
+		if name != sub.Name {
+			sub.DisplayName = sub.Name
+			sub.Name = name
+		}
+	}
+}
+
+// subTestNamer gives unique names to subtests of the same parent, as testing.matcher.unique does.
+type subTestNamer struct {
+	// counts of names used, which are prefixed with a slash for the parent name omitted
+	subNames map[string]int32
+}
+
+func newSubTestNamer() *subTestNamer {
+	return &subTestNamer{subNames: make(map[string]int32)}
+}
+
+func (n *subTestNamer) unique(subname string) string {
+	base := "/" + subname
+	for {
+		c := n.subNames[base]
+		n.subNames[base] = c + 1
+
+		if c == 0 && subname != "" {
+			prefix, nn := parseSubTestNumber(base)
+			if len(prefix) < len(base) && nn < n.subNames[prefix] {
+				// explicitly named like "subname#NN", which was already used for the NNth occurrence of "subname"
+				continue
+			}
+			return base[1:]
+		}
+
+		name := fmt.Sprintf("%s#%02d", base, c)
+		if n.subNames[name] != 0 {
+			// collides with a subtest explicitly named like "subname#NN"
+			continue
+		}
+		return name[1:]
+	}
+}
+
+// parseSubTestNumber splits the name into the prefix and the #NN suffix, if present.
+func parseSubTestNumber(s string) (string, int32) {
+	i := strings.LastIndex(s, "#")
+	if i < 0 {
+		return s, 0
+	}
+	prefix, suffix := s[:i], s[i+1:]
+	if len(suffix) < 2 || (len(suffix) > 2 && suffix[0] == '0') {
+		return s, 0
+	}
+	if suffix == "00" && !strings.HasSuffix(prefix, "/") {
+		// #00 is only used for subtests with the empty name
+		return s, 0
+	}
+	n, err := strconv.ParseInt(suffix, 10, 32)
+	if err != nil || n < 0 {
+		return s, 0
+	}
+	return prefix, int32(n)
+}
+
+// rewriteSubTestName replaces spaces with underscores and escapes non-printable characters, as testing.rewrite does.
+func rewriteSubTestName(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case isSubTestNameSpace(r):
+			b.WriteByte('_')
+		case !strconv.IsPrint(r):
+			q := strconv.QuoteRune(r)
+			b.WriteString(q[1 : len(q)-1])
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// isSubTestNameSpace reports whether go test rewrites the rune as a space, which is not the same as the Unicode Z class.
+func isSubTestNameSpace(r rune) bool {
+	if r < 0x2000 {
+		switch r {
+		case '\t', '\n', '\v', '\f', '\r', ' ', 0x85, 0xA0, 0x1680:
+			return true
+		}
+		return false
+	}
+	if r <= 0x200a {
+		return true
+	}
+	switch r {
+	case 0x2028, 0x2029, 0x202f, 0x205f, 0x3000:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,103 @@
+package parse
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"strings"
+
+	"github.com/lusingander/gotip/internal/tip"
+	"golang.org/x/tools/go/packages"
+)
+
+// ProcessPackagesRecursively finds tests like ProcessFilesRecursively, but resolves subtest names with the type information
+// of the packages loaded by the go command, which respects build tags and go.work.
+// The test files excluded by build constraints for the build configuration are skipped, and those outside the loaded packages are parsed without type information.
+func ProcessPackagesRecursively(rootDir string, ignore []string, skipSubtests bool, build tip.BuildConfig) (map[string][]*tip.TestFunction, error) {
+	result := tip.CollectDiscoveryResults(DiscoverPackagesRecursively(context.Background(), rootDir, ignore, skipSubtests, build))
+	return result.Tests, errors.Join(result.Errors...)
+}
+
+// DiscoverPackagesRecursively finds tests like ProcessPackagesRecursively in the background, as DiscoverFilesRecursively does.
+// If the packages cannot be loaded, the error is sent as the only result.
+func DiscoverPackagesRecursively(ctx context.Context, rootDir string, ignore []string, skipSubtests bool, build tip.BuildConfig) <-chan *tip.DiscoveryResult {
+	results := make(chan *tip.DiscoveryResult)
+	go func() {
+		scopes, err := loadTypedPackageScopes(ctx, rootDir, build)
+		if err != nil {
+			select {
+			case results <- &tip.DiscoveryResult{Errors: []error{err}}:
+			case <-ctx.Done():
+			}
+			close(results)
+			return
+		}
+		// the type information depends on other packages, so the results are not cached
+		discoverFiles(ctx, rootDir, ignore, skipSubtests, scopes, nil, results)
+	}()
+	return results
+}
+
+func loadTypedPackageScopes(ctx context.Context, rootDir string, build tip.BuildConfig) (*packageScopes, error) {
+	absRoot, err := filepath.Abs(rootDir)
+	if err != nil {
+		return nil, err
+	}
+	scopes := newPackageScopes()
+	scopes.typed = true
+	scopes.build = buildContext(build)
+	conf := &packages.Config{
+		Context:    ctx,
+		Mode:       packages.NeedName | packages.NeedFiles | packages.NeedForTest | packages.NeedSyntax | packages.NeedTypes | packages.NeedTypesInfo,
+		Dir:        rootDir,
+		Env:        build.Env(),
+		BuildFlags: build.BuildFlags(),
+		Tests:      true,
+		Fset:       scopes.fset,
+		ParseFile: func(fset *token.FileSet, filename string, src []byte) (*ast.File, error) {
+			// positions are reported relative to the root directory as the files found by walking it
+			path := relativeFilePath(rootDir, absRoot, filename)
+			f, err := parser.ParseFile(fset, path, src, parser.ParseComments|parser.AllErrors)
+			scopes.addParseErrors(path, err)
+			return f, err
+		},
+	}
+	pkgs, err := packages.Load(conf, "./...")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load packages: %w", err)
+	}
+	for _, pkg := range pkgs {
+		if pkg.ForTest == "" || pkg.TypesInfo == nil {
+			// test files are only included in the test variants of the packages
+			continue
+		}
+		scope := newPackageScope(pkg.TypesInfo)
+		for _, f := range pkg.Syntax {
+			scope.addFile(f)
+			path := scopes.fset.Position(f.Pos()).Filename
+			scopes.files[filepath.Clean(path)] = f
+			scopes.scopes[packageScopeKey(filepath.Dir(path), f)] = scope
+		}
+	}
+	for _, pkg := range pkgs {
+		for _, name := range pkg.IgnoredFiles {
+			path := filepath.Clean(relativeFilePath(rootDir, absRoot, name))
+			if _, ok := scopes.files[path]; !ok {
+				scopes.ignored[path] = true
+			}
+		}
+	}
+	return scopes, nil
+}
+
+func relativeFilePath(rootDir, absRoot, filename string) string {
+	rel, err := filepath.Rel(absRoot, filename)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return filename
+	}
+	return filepath.Join(rootDir, rel)
+}
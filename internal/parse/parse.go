@@ -1,11 +1,20 @@
 package parse
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"go/ast"
-	"go/parser"
+	"go/doc"
 	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
 	"unicode"
 	"unicode/utf8"
 
@@ -18,7 +27,64 @@ var defaultIgnoreDirs = []string{
 	"testdata",
 }
 
-func ProcessFilesRecursively(rootDir string, ignore []string, skipSubtests bool) (map[string][]*tip.TestFunction, error) {
+// ProcessFilesRecursively finds tests in the test files under rootDir by parsing them.
+// The files excluded by build constraints for the build configuration are skipped, as go test does not compile them.
+// If cache is not nil, the tests of the packages whose files are unchanged are taken from it,
+// and it is updated with the tests discovered this time.
+// The errors of the files which cannot be processed are returned together with the tests found in the others.
+func ProcessFilesRecursively(rootDir string, ignore []string, skipSubtests bool, build tip.BuildConfig, cache *tip.DiscoveryCache) (map[string][]*tip.TestFunction, error) {
+	result := tip.CollectDiscoveryResults(DiscoverFilesRecursively(context.Background(), rootDir, ignore, skipSubtests, build, cache))
+	return result.Tests, errors.Join(result.Errors...)
+}
+
+// DiscoverFilesRecursively finds tests like ProcessFilesRecursively in the background,
+// and sends the result of each package to the returned channel as soon as its files are processed.
+// Syntax errors are reported as diagnostics of the files, and the tests found in the partially parsed files are included.
+// Files which cannot be processed are reported in the results, and do not prevent discovering tests in the others.
+// The channel is closed once all packages are processed and the cache is updated.
+// When ctx is canceled, the remaining packages are skipped and the results are no longer sent,
+// but the channel is closed only after the cache is updated with the packages processed so far.
+func DiscoverFilesRecursively(ctx context.Context, rootDir string, ignore []string, skipSubtests bool, build tip.BuildConfig, cache *tip.DiscoveryCache) <-chan *tip.DiscoveryResult {
+	results := make(chan *tip.DiscoveryResult)
+	scopes := newPackageScopes()
+	scopes.build = buildContext(build)
+	go discoverFiles(ctx, rootDir, ignore, skipSubtests, scopes, newDiscoveryCache(cache, skipSubtests, scopes.build), results)
+	return results
+}
+
+// discoverFiles processes the test files of each package in parallel, and closes results when done.
+// The files of a package are processed by the same worker, as they share the package scope.
+func discoverFiles(ctx context.Context, rootDir string, ignore []string, skipSubtests bool, scopes *packageScopes, cache *discoveryCache, results chan<- *tip.DiscoveryResult) {
+	defer close(results)
+
+	packages := testFilesByDir(rootDir, ignore)
+	jobs := make(chan []string)
+	var wg sync.WaitGroup
+	for range min(runtime.GOMAXPROCS(0), len(packages)) {
+		wg.Go(func() {
+			for paths := range jobs {
+				select {
+				case results <- processPackageFiles(paths, skipSubtests, scopes, cache):
+				case <-ctx.Done():
+					// nobody receives the results anymore
+				}
+			}
+		})
+	}
+	for _, paths := range packages {
+		if ctx.Err() != nil {
+			break
+		}
+		jobs <- paths
+	}
+	close(jobs)
+	wg.Wait()
+
+	cache.flush(ctx.Err() == nil)
+}
+
+// testFilesByDir returns the paths of the test files under rootDir, grouped by directory in the order they are found.
+func testFilesByDir(rootDir string, ignore []string) [][]string {
 	fileListQueue := make(chan *gocodewalker.File, 100)
 
 	fileWalker := gocodewalker.NewFileWalker(rootDir, fileListQueue)
@@ -28,109 +94,279 @@ func ProcessFilesRecursively(rootDir string, ignore []string, skipSubtests bool)
 
 	go fileWalker.Start()
 
-	tests := make(map[string][]*tip.TestFunction)
+	dirs := make([][]string, 0)
+	dirIndex := make(map[string]int)
 	for f := range fileListQueue {
 		// fileWalker.IncludeFilenameRegex should not be used to select _test.go files as it seems to override ignore settings
 		if !strings.HasSuffix(f.Location, "_test.go") {
 			continue
 		}
-		testFunctions, err := processFile(f.Location, skipSubtests)
-		if err != nil {
-			return nil, fmt.Errorf("error processing file %s: %w", f.Location, err)
+		dir := filepath.Dir(f.Location)
+		if i, ok := dirIndex[dir]; ok {
+			dirs[i] = append(dirs[i], f.Location)
+			continue
 		}
-		tests[f.Location] = testFunctions
+		dirIndex[dir] = len(dirs)
+		dirs = append(dirs, []string{f.Location})
 	}
+	return dirs
+}
 
-	return tests, nil
+// processPackageFiles processes the test files in the same directory.
+func processPackageFiles(paths []string, skipSubtests bool, scopes *packageScopes, cache *discoveryCache) *tip.DiscoveryResult {
+	key, cacheable := cache.packageKey(filepath.Dir(paths[0]))
+	result := &tip.DiscoveryResult{
+		Dir:         filepath.Dir(paths[0]),
+		Tests:       make(map[string][]*tip.TestFunction),
+		Diagnostics: make(map[string][]tip.Diagnostic),
+	}
+	for _, path := range paths {
+		if cacheable {
+			if testFunctions, ok := cache.get(path, key); ok {
+				result.Tests[path] = testFunctions
+				continue
+			}
+		}
+		if scopes.excluded(path) {
+			continue
+		}
+		testFunctions, err := processFile(path, skipSubtests, scopes)
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Errorf("error processing file %s: %w", path, err))
+			continue
+		}
+		result.Tests[path] = testFunctions
+		if diagnostics := scopes.diagnostics(path); len(diagnostics) > 0 {
+			for _, tf := range testFunctions {
+				tf.FileHasErrors = true
+			}
+			// the file is likely being edited, so it is parsed again next time instead of caching the diagnostics
+			result.Diagnostics[path] = diagnostics
+			continue
+		}
+		if cacheable {
+			cache.put(path, key, testFunctions)
+		}
+	}
+	return result
 }
 
-func processFile(path string, skipSubtests bool) ([]*tip.TestFunction, error) {
-	fset := token.NewFileSet()
-	node, err := parser.ParseFile(fset, path, nil, 0)
+func processFile(path string, skipSubtests bool, scopes *packageScopes) ([]*tip.TestFunction, error) {
+	fset := scopes.fset
+	node, err := scopes.parseFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse file %s: %w", path, err)
 	}
+	var scope *packageScope
+	if !skipSubtests || scopes.typed {
+		// the type information is used to find test functions as well
+		scope = scopes.scopeOf(path, node)
+	}
+	suitePkg := importName(node, testifySuitePath)
+	examples := make(map[string]*doc.Example)
+	for _, ex := range doc.Examples(node) {
+		examples["Example"+ex.Name] = ex
+	}
 	testFunctions := make([]*tip.TestFunction, 0)
 	for _, decl := range node.Decls {
 		fn, ok := decl.(*ast.FuncDecl)
-		if !ok || !isTestFunction(fn) {
+		if !ok {
+			continue
+		}
+		kind, ok := testFunctionKind(fn, scope)
+		if !ok {
 			continue
 		}
-		testFunctions = append(testFunctions, processTestFunction(fn, skipSubtests))
+		switch kind {
+		case tip.TestKindFuzz:
+			testFunctions = append(testFunctions, processFuzzFunction(fset, fn, path, skipSubtests))
+			continue
+		case tip.TestKindExample:
+			if ex, ok := examples[fn.Name.Name]; ok {
+				testFunctions = append(testFunctions, processExampleFunction(fset, fn, ex))
+			}
+			continue
+		}
+		testFunctions = append(testFunctions, processTestFunction(fset, fn, kind, skipSubtests, scope, suitePkg))
 	}
 	return testFunctions, nil
 }
 
-func isTestFunction(fn *ast.FuncDecl) bool {
-	if fn.Recv != nil || fn.Body == nil || !isTestName(fn.Name.Name) {
-		return false
+func testFunctionKind(fn *ast.FuncDecl, scope *packageScope) (tip.TestKind, bool) {
+	if fn.Recv != nil || fn.Body == nil {
+		return 0, false
+	}
+
+	name := fn.Name.Name
+	if isTestName(name, "Example") {
+		return tip.TestKindExample, fn.Type.Params.NumFields() == 0 && fn.Type.Results.NumFields() == 0
 	}
 	if fn.Type.Params == nil || len(fn.Type.Params.List) != 1 {
-		return false
+		return 0, false
 	}
 
-	return isTestingTType(fn.Type.Params.List[0].Type)
+	param := fn.Type.Params.List[0].Type
+	switch {
+	case isTestName(name, "Test") && isTestingType(scope, param, "T"):
+		return tip.TestKindTest, true
+	case isTestName(name, "Benchmark") && isTestingType(scope, param, "B"):
+		return tip.TestKindBenchmark, true
+	case isTestName(name, "Fuzz") && isTestingType(scope, param, "F"):
+		return tip.TestKindFuzz, true
+	}
+	return 0, false
 }
 
-func isTestName(name string) bool {
-	if !strings.HasPrefix(name, "Test") {
+func isTestName(name, prefix string) bool {
+	if !strings.HasPrefix(name, prefix) {
 		return false
 	}
-	if len(name) == len("Test") {
+	if len(name) == len(prefix) {
 		return true
 	}
-	r, _ := utf8.DecodeRuneInString(name[len("Test"):])
-	// Match go test: Test followed by a non-lowercase rune is a test.
+	r, _ := utf8.DecodeRuneInString(name[len(prefix):])
+	// Match go test: the prefix followed by a non-lowercase rune is a test.
 	return !unicode.IsLower(r)
 }
 
-func processTestFunction(fn *ast.FuncDecl, skipSubtests bool) *tip.TestFunction {
+func processTestFunction(fset *token.FileSet, fn *ast.FuncDecl, kind tip.TestKind, skipSubtests bool, scope *packageScope, suitePkg string) *tip.TestFunction {
 	if skipSubtests {
 		return &tip.TestFunction{
 			Name: fn.Name.Name,
+			Kind: kind,
 			Subs: []*tip.SubTest{},
+			Pos:  toPosition(fset, fn.Pos()),
 		}
 	}
 
-	unresolvedSubTests := findSubTests(fn.Body.List, testingTParamNames(fn.Type.Params))
+	unresolvedSubTests := findSubTests(fn.Body.List, testingParamNames(scope, fn.Type.Params), scope, 0, scope.contexts...)
+	unresolvedSubTests = append(unresolvedSubTests, findSuiteSubTests(fn, suitePkg, scope)...)
 
 	subs := make([]*tip.SubTest, 0)
 	for _, sub := range unresolvedSubTests {
-		subs = append(subs, sub.resolve()...)
+		subs = append(subs, sub.resolve(fset)...)
 	}
+	rewriteSubTestNames(subs)
 
 	return &tip.TestFunction{
 		Name: fn.Name.Name,
+		Kind: kind,
 		Subs: subs,
+		Pos:  toPosition(fset, fn.Pos()),
+	}
+}
+
+func toPosition(fset *token.FileSet, pos token.Pos) tip.Position {
+	if !pos.IsValid() {
+		return tip.Position{}
+	}
+	p := fset.Position(pos)
+	return tip.Position{
+		File:   p.Filename,
+		Line:   p.Line,
+		Column: p.Column,
+	}
+}
+
+func processExampleFunction(fset *token.FileSet, fn *ast.FuncDecl, ex *doc.Example) *tip.TestFunction {
+	return &tip.TestFunction{
+		Name:      fn.Name.Name,
+		Kind:      tip.TestKindExample,
+		Subs:      []*tip.SubTest{},
+		Pos:       toPosition(fset, fn.Pos()),
+		HasOutput: ex.Output != "" || ex.EmptyOutput,
+	}
+}
+
+// processFuzzFunction treats the seed corpus files in testdata/fuzz/FuzzXxx as subtests,
+// since go test runs each of them as FuzzXxx/<file name>.
+func processFuzzFunction(fset *token.FileSet, fn *ast.FuncDecl, path string, skipSubtests bool) *tip.TestFunction {
+	subs := make([]*tip.SubTest, 0)
+	if !skipSubtests {
+		corpusDir := filepath.Join(filepath.Dir(path), "testdata", "fuzz", fn.Name.Name)
+		for _, entry := range seedCorpusEntries(corpusDir) {
+			subs = append(subs, &tip.SubTest{
+				Name:     entry,
+				Resolved: true,
+				Subs:     []*tip.SubTest{},
+				Pos: tip.Position{
+					File:   filepath.Join(corpusDir, entry),
+					Line:   1,
+					Column: 1,
+				},
+			})
+		}
+	}
+	return &tip.TestFunction{
+		Name: fn.Name.Name,
+		Kind: tip.TestKindFuzz,
+		Subs: subs,
+		Pos:  toPosition(fset, fn.Pos()),
+	}
+}
+
+func seedCorpusEntries(corpusDir string) []string {
+	entries, err := os.ReadDir(corpusDir)
+	if err != nil {
+		// no seed corpus
+		return nil
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		names = append(names, entry.Name())
 	}
+	return names
 }
 
-func findSubTests(stmts []ast.Stmt, testingTReceivers []string, cs ...subTestContext) []*unresolvedSubTest {
+// findSubTests returns the subtests started in the statements.
+// helperDepth is the number of helper function calls followed to reach the statements.
+func findSubTests(stmts []ast.Stmt, testingTReceivers []string, scope *packageScope, helperDepth int, cs ...subTestContext) []*unresolvedSubTest {
 	newCs := append([]subTestContext{}, cs...)
 	subs := make([]*unresolvedSubTest, 0)
 	for _, stmt := range stmts {
 		switch s := stmt.(type) {
+		case *ast.IfStmt:
+			// the variables declared in the init statement are visible in both branches
+			branches := []ast.Stmt{s.Body}
+			if s.Else != nil {
+				branches = append(branches, s.Else)
+			}
+			if s.Init != nil {
+				branches = append([]ast.Stmt{s.Init}, branches...)
+			}
+			subs = append(subs, findSubTests(branches, testingTReceivers, scope, helperDepth, newCs...)...)
 		case *ast.ExprStmt:
 			call, ok := s.X.(*ast.CallExpr)
 			if !ok {
 				continue
 			}
+			if helper, ok := call.Fun.(*ast.Ident); ok {
+				subs = append(subs, findSubTestsInHelperCall(call, helper, testingTReceivers, scope, helperDepth, newCs...)...)
+				continue
+			}
 			sel, ok := call.Fun.(*ast.SelectorExpr)
 			if !ok || sel.Sel.Name != "Run" || len(call.Args) < 2 || !isTestingTRunSelector(sel, testingTReceivers) {
 				continue
 			}
-			subs = append(subs, findSubTest(call.Args, newCs...))
+			subs = append(subs, findSubTest(call, testingTReceivers, scope, helperDepth, newCs...))
 		case *ast.BlockStmt:
-			subs = append(subs, findSubTests(s.List, testingTReceivers, newCs...)...)
+			subs = append(subs, findSubTests(s.List, testingTReceivers, scope, helperDepth, newCs...)...)
 		case *ast.ForStmt:
-			subs = append(subs, findSubTests(s.Body.List, testingTReceivers, newCs...)...)
+			subs = append(subs, findSubTests(s.Body.List, testingTReceivers, scope, helperDepth, newCs...)...)
 		case *ast.RangeStmt:
 			if c := forRangeContextFromRangeStmt(s); c != nil {
 				newCs = append(newCs, c)
 			}
-			subs = append(subs, findSubTests(s.Body.List, testingTReceivers, newCs...)...)
+			subs = append(subs, findSubTests(s.Body.List, testingTReceivers, scope, helperDepth, newCs...)...)
 		case *ast.AssignStmt:
+			if names := testingTypeAssertionNames(scope, s, testingTReceivers); len(names) > 0 {
+				testingTReceivers = append(slices.Clone(testingTReceivers), names...)
+			}
 			newCs = append(newCs, findStringIdentContextsFromAssignStmt(s)...)
+			newCs = append(newCs, findTableAliasesFromAssignStmt(s, newCs...)...)
 			if c := findStructSliceLiteralDeclarationFromAssignStmt(s); c != nil {
 				newCs = append(newCs, c)
 			}
@@ -138,7 +374,9 @@ func findSubTests(stmts []ast.Stmt, testingTReceivers []string, cs ...subTestCon
 				newCs = append(newCs, c)
 			}
 		case *ast.DeclStmt:
+			newCs = append(newCs, findTypeDeclarationsFromDeclStmt(s)...)
 			newCs = append(newCs, findStringIdentContextsFromDeclStmt(s)...)
+			newCs = append(newCs, findTableAliasesFromDeclStmt(s, newCs...)...)
 			if c := findStructSliceLiteralDeclarationFromDeclStmt(s); c != nil {
 				newCs = append(newCs, c)
 			}
@@ -150,6 +388,25 @@ func findSubTests(stmts []ast.Stmt, testingTReceivers []string, cs ...subTestCon
 	return subs
 }
 
+// testingTypeAssertionNames returns the variables asserted from testing.TB to *testing.T, such as `t, ok := tb.(*testing.T)`.
+func testingTypeAssertionNames(scope *packageScope, assign *ast.AssignStmt, testingTReceivers []string) []string {
+	if len(assign.Rhs) != 1 || len(assign.Lhs) == 0 {
+		return nil
+	}
+	assert, ok := assign.Rhs[0].(*ast.TypeAssertExpr)
+	if !ok || !isTestingParamType(scope, assert.Type) {
+		return nil
+	}
+	x, ok := assert.X.(*ast.Ident)
+	if !ok || !slices.Contains(testingTReceivers, x.Name) {
+		return nil
+	}
+	if ident, ok := assign.Lhs[0].(*ast.Ident); ok && ident.Name != "_" {
+		return []string{ident.Name}
+	}
+	return nil
+}
+
 func isTestingTRunSelector(sel *ast.SelectorExpr, testingTReceivers []string) bool {
 	ident, ok := sel.X.(*ast.Ident)
 	if !ok {
@@ -163,13 +420,15 @@ func isTestingTRunSelector(sel *ast.SelectorExpr, testingTReceivers []string) bo
 	return false
 }
 
-func testingTParamNames(params *ast.FieldList) []string {
+// testingParamNames returns the names of the *testing.T and *testing.B parameters,
+// both of which can start subtests (or sub-benchmarks) with Run, and testing.TB parameters of helper functions.
+func testingParamNames(scope *packageScope, params *ast.FieldList) []string {
 	if params == nil {
 		return nil
 	}
 	names := make([]string, 0)
 	for _, param := range params.List {
-		if !isTestingTType(param.Type) {
+		if !isTestingParamType(scope, param.Type) {
 			continue
 		}
 		for _, name := range param.Names {
@@ -179,13 +438,39 @@ func testingTParamNames(params *ast.FieldList) []string {
 	return names
 }
 
-func isTestingTType(expr ast.Expr) bool {
+func isTestingParamType(scope *packageScope, expr ast.Expr) bool {
+	return isTestingType(scope, expr, "T") || isTestingType(scope, expr, "B") || isTestingSelector(scope, expr, "TB")
+}
+
+// isTestingType reports whether the type is a pointer to the type of the testing package, such as *testing.T.
+// The type information of the package is used if loaded, which also recognizes renamed imports of the testing package.
+func isTestingType(scope *packageScope, expr ast.Expr, name string) bool {
+	if typ := scope.typeOf(expr); typ != nil {
+		ptr, ok := typ.(*types.Pointer)
+		return ok && isTestingNamedType(ptr.Elem(), name)
+	}
 	star, ok := expr.(*ast.StarExpr)
 	if !ok {
 		return false
 	}
-	sel, ok := star.X.(*ast.SelectorExpr)
-	if !ok || sel.Sel.Name != "T" {
+	return isTestingSelector(scope, star.X, name)
+}
+
+func isTestingNamedType(typ types.Type, name string) bool {
+	named, ok := types.Unalias(typ).(*types.Named)
+	if !ok {
+		return false
+	}
+	obj := named.Obj()
+	return obj.Pkg() != nil && obj.Pkg().Path() == "testing" && obj.Name() == name
+}
+
+func isTestingSelector(scope *packageScope, expr ast.Expr, name string) bool {
+	if typ := scope.typeOf(expr); typ != nil {
+		return isTestingNamedType(typ, name)
+	}
+	sel, ok := expr.(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != name {
 		return false
 	}
 	pkg, ok := sel.X.(*ast.Ident)
@@ -333,9 +618,10 @@ func findStringIdentContextsFromDeclStmt(decl *ast.DeclStmt) []subTestContext {
 	return cs
 }
 
-func findSubTest(exprs []ast.Expr, cs ...subTestContext) *unresolvedSubTest {
+func findSubTest(call *ast.CallExpr, testingTReceivers []string, scope *packageScope, helperDepth int, cs ...subTestContext) *unresolvedSubTest {
 	var name unresolvedSubTestName
 
+	exprs := call.Args
 	switch e := exprs[0].(type) {
 	case *ast.BasicLit:
 		name = findSubTestNameFromBasicLit(e)
@@ -345,25 +631,30 @@ func findSubTest(exprs []ast.Expr, cs ...subTestContext) *unresolvedSubTest {
 		}
 	case *ast.Ident:
 		name = findSubTestNameFromIdent(e, cs...)
-	case *ast.BinaryExpr:
-		// If it's a binary expression, we can't resolve it to a specific name without more context.
-		// This might happen in cases like `t.Run("test"+string(i), ...)`.
-	case *ast.CallExpr:
-		// If it's a call expression, we can't resolve it to a specific name without more context
-		// This might happen in cases like `t.Run(fmt.Sprintf("test%d", i), ...)`.
 	}
 
-	if name == nil {
-		name = &unknownSubTestName{}
+	if name == nil || !isResolved(name) {
+		// names computed from constants or table entries, such as `t.Run(fmt.Sprintf("%s_%d", tt.name, tt.n), ...)`.
+		// This cannot resolve names that depend on values computed at run time, such as `t.Run("test"+strconv.Itoa(i), ...)`.
+		if n := findSubTestNameFromExpr(exprs[0], scope, cs...); isResolved(n) || name == nil {
+			name = n
+		}
 	}
 
 	var subs []*unresolvedSubTest
 	if fnLit, ok := exprs[1].(*ast.FuncLit); ok {
-		subs = findSubTests(fnLit.Body.List, testingTParamNames(fnLit.Type.Params))
+		// variables of the enclosing function refer to a single test case in the subtest, so only package-level ones are kept
+		receivers := testingParamNames(scope, fnLit.Type.Params)
+		if fnLit.Type.Params.NumFields() == 0 {
+			// subtests of testify suites take no parameters, and start their subtests with the suite again
+			receivers = testingTReceivers
+		}
+		subs = findSubTests(fnLit.Body.List, receivers, scope, helperDepth, scope.contexts...)
 	}
 
 	return &unresolvedSubTest{
 		name: name,
+		pos:  call.Pos(),
 		subs: subs,
 	}
 }
@@ -372,8 +663,9 @@ func findSubTestNameFromBasicLit(lit *ast.BasicLit) *literalSubTestName {
 	if lit.Kind != token.STRING {
 		return nil
 	}
+	name, _ := stringLiteralValue(lit)
 	return &literalSubTestName{
-		name: strings.Trim(lit.Value, `"`),
+		name: name,
 	}
 }
 
@@ -402,7 +694,7 @@ func findSubTestNameFromSelectorExpr(sel *ast.SelectorExpr, cs ...subTestContext
 			if structSliceCtx.ident != forRangeCtx.iterIdent {
 				continue
 			}
-			n.cases = structSliceCtx.extractTestCaseName(n.field)
+			n.cases = structSliceCtx.extractTestCaseName(n.field, cs...)
 		}
 	}
 	return n
@@ -416,7 +708,7 @@ func findSubTestNameFromIdent(ident *ast.Ident, cs ...subTestContext) *identSubT
 		switch c := cs[i].(type) {
 		case *stringIdentContext:
 			if n.name == c.ident {
-				n.cases = []string{c.value}
+				n.cases = []testCaseName{{name: c.value}}
 				return n
 			}
 		case *forRangeContext:
@@ -429,7 +721,7 @@ func findSubTestNameFromIdent(ident *ast.Ident, cs ...subTestContext) *identSubT
 	return n
 }
 
-func findMapTestCaseNames(ident string, cs ...subTestContext) []string {
+func findMapTestCaseNames(ident string, cs ...subTestContext) []testCaseName {
 	for i := len(cs) - 1; i >= 0; i-- {
 		mapCtx, ok := cs[i].(*mapLiteralDeclarationContext)
 		if !ok {
@@ -444,68 +736,87 @@ func findMapTestCaseNames(ident string, cs ...subTestContext) []string {
 
 type unresolvedSubTest struct {
 	name unresolvedSubTestName
+	pos  token.Pos // position of the Run call
 	subs []*unresolvedSubTest
 }
 
-func (t *unresolvedSubTest) resolve() []*tip.SubTest {
+func (t *unresolvedSubTest) resolve(fset *token.FileSet) []*tip.SubTest {
 	subTests := make([]*tip.SubTest, 0)
 	for _, sub := range t.subs {
-		subTests = append(subTests, sub.resolve()...)
+		subTests = append(subTests, sub.resolve(fset)...)
 	}
+	rewriteSubTestNames(subTests)
 	tests := make([]*tip.SubTest, 0)
 	ns, resolved := t.name.resolveTestName()
 	for _, n := range ns {
+		// prefer the position of the table entry that defines the name
+		pos := n.pos
+		if !pos.IsValid() {
+			pos = t.pos
+		}
 		test := &tip.SubTest{
-			Name:     n,
+			Name:     n.name,
 			Resolved: resolved,
 			Subs:     subTests,
+			Pos:      toPosition(fset, pos),
 		}
 		tests = append(tests, test)
 	}
 	return tests
 }
 
+// testCaseName is a resolved subtest name with the position of the table entry that defines it, if any.
+type testCaseName struct {
+	name string
+	pos  token.Pos
+}
+
 type unresolvedSubTestName interface {
-	resolveTestName() ([]string, bool)
+	resolveTestName() ([]testCaseName, bool)
+}
+
+func isResolved(n unresolvedSubTestName) bool {
+	_, resolved := n.resolveTestName()
+	return resolved
 }
 
 type literalSubTestName struct {
 	name string
 }
 
-func (l *literalSubTestName) resolveTestName() ([]string, bool) {
-	return []string{l.name}, true
+func (l *literalSubTestName) resolveTestName() ([]testCaseName, bool) {
+	return []testCaseName{{name: l.name}}, true
 }
 
 type selectorSubTestName struct {
 	receiver string
 	field    string
-	cases    []string
+	cases    []testCaseName
 }
 
-func (s *selectorSubTestName) resolveTestName() ([]string, bool) {
+func (s *selectorSubTestName) resolveTestName() ([]testCaseName, bool) {
 	if len(s.cases) == 0 {
-		return []string{""}, false
+		return []testCaseName{{}}, false
 	}
 	return s.cases, true
 }
 
 type identSubTestName struct {
 	name  string
-	cases []string
+	cases []testCaseName
 }
 
-func (i *identSubTestName) resolveTestName() ([]string, bool) {
+func (i *identSubTestName) resolveTestName() ([]testCaseName, bool) {
 	if len(i.cases) > 0 {
 		return i.cases, true
 	}
-	return []string{""}, false
+	return []testCaseName{{}}, false
 }
 
 type unknownSubTestName struct{}
 
-func (u *unknownSubTestName) resolveTestName() ([]string, bool) {
-	return []string{""}, false
+func (u *unknownSubTestName) resolveTestName() ([]testCaseName, bool) {
+	return []testCaseName{{}}, false
 }
 
 type subTestContext interface{}
@@ -520,8 +831,8 @@ type mapLiteralDeclarationContext struct {
 	compLit *ast.CompositeLit
 }
 
-func (c *mapLiteralDeclarationContext) extractTestCaseNames() []string {
-	ns := make([]string, 0)
+func (c *mapLiteralDeclarationContext) extractTestCaseNames() []testCaseName {
+	ns := make([]testCaseName, 0)
 	if !c.isStringKeyMap() {
 		return ns
 	}
@@ -530,12 +841,11 @@ func (c *mapLiteralDeclarationContext) extractTestCaseNames() []string {
 		if !ok {
 			continue
 		}
-		key, ok := kv.Key.(*ast.BasicLit)
-		if !ok || key.Kind != token.STRING {
+		n, ok := stringLiteralValue(kv.Key)
+		if !ok {
 			continue
 		}
-		n := strings.Trim(key.Value, `"`)
-		ns = append(ns, n)
+		ns = append(ns, testCaseName{name: n, pos: kv.Pos()})
 	}
 	return ns
 }
@@ -554,7 +864,12 @@ func stringLiteralValue(expr ast.Expr) (string, bool) {
 	if !ok || lit.Kind != token.STRING {
 		return "", false
 	}
-	return strings.Trim(lit.Value, `"`), true
+	// both interpreted and raw string literals, with escape sequences replaced
+	s, err := strconv.Unquote(lit.Value)
+	if err != nil {
+		return "", false
+	}
+	return s, true
 }
 
 type structSliceLiteralDeclarationContext struct {
@@ -562,47 +877,77 @@ type structSliceLiteralDeclarationContext struct {
 	compLit *ast.CompositeLit
 }
 
-func (c *structSliceLiteralDeclarationContext) extractTestCaseName(name string) []string {
-	caseFieldIdx := c.findCaseNameFieldIndex(name)
-	ns := make([]string, 0)
+func (c *structSliceLiteralDeclarationContext) extractTestCaseName(name string, cs ...subTestContext) []testCaseName {
+	structType := resolveStructType(c.compLit.Type.(*ast.ArrayType).Elt, cs...)
+	ns := make([]testCaseName, 0)
 	for _, elt := range c.compLit.Elts {
-		st, ok := elt.(*ast.CompositeLit)
+		st, ok := compositeLitOf(elt)
 		if !ok {
 			continue
 		}
-		for i, elt := range st.Elts {
-			switch e := elt.(type) {
-			case *ast.BasicLit:
-				if i == caseFieldIdx && e.Kind == token.STRING {
-					n := strings.Trim(e.Value, `"`)
-					ns = append(ns, n)
-				}
-			case *ast.KeyValueExpr:
-				if keyIdent, ok := e.Key.(*ast.Ident); ok {
-					if keyIdent.Name == name {
-						if e, ok := e.Value.(*ast.BasicLit); ok {
-							if e.Kind == token.STRING {
-								n := strings.Trim(e.Value, `"`)
-								ns = append(ns, n)
-							}
-						}
-					}
-				}
-			}
+		field, ok := structLiteralFields(structType, st)[name]
+		if !ok || field.expr == nil {
+			continue
+		}
+		if n, ok := stringLiteralValue(field.expr); ok {
+			ns = append(ns, testCaseName{name: n, pos: elt.Pos()})
 		}
 	}
 	return ns
 }
 
-func (c *structSliceLiteralDeclarationContext) findCaseNameFieldIndex(name string) int {
-	if st, ok := c.compLit.Type.(*ast.ArrayType).Elt.(*ast.StructType); ok {
-		for i, field := range st.Fields.List {
-			if len(field.Names) == 1 && field.Names[0].Name == name {
-				return i
+// compositeLitOf returns the struct literal of a table entry, which may be written as &T{...}.
+func compositeLitOf(expr ast.Expr) (*ast.CompositeLit, bool) {
+	if unary, ok := expr.(*ast.UnaryExpr); ok && unary.Op == token.AND {
+		expr = unary.X
+	}
+	lit, ok := expr.(*ast.CompositeLit)
+	return lit, ok
+}
+
+type typeDeclarationContext struct {
+	ident      string
+	structType *ast.StructType
+}
+
+func findTypeDeclarationsFromDeclStmt(decl *ast.DeclStmt) []subTestContext {
+	genDecl, ok := decl.Decl.(*ast.GenDecl)
+	if !ok || genDecl.Tok != token.TYPE {
+		return nil
+	}
+	cs := make([]subTestContext, 0)
+	for _, spec := range genDecl.Specs {
+		typeSpec, ok := spec.(*ast.TypeSpec)
+		if !ok || typeSpec.TypeParams != nil {
+			continue
+		}
+		if st, ok := typeSpec.Type.(*ast.StructType); ok {
+			cs = append(cs, &typeDeclarationContext{
+				ident:      typeSpec.Name.Name,
+				structType: st,
+			})
+		}
+	}
+	return cs
+}
+
+// resolveStructType returns the struct type that the type of table entries refers to, such as T, *T or struct{...}.
+func resolveStructType(typ ast.Expr, cs ...subTestContext) *ast.StructType {
+	switch t := typ.(type) {
+	case *ast.StructType:
+		return t
+	case *ast.StarExpr:
+		return resolveStructType(t.X, cs...)
+	case *ast.ParenExpr:
+		return resolveStructType(t.X, cs...)
+	case *ast.Ident:
+		for i := len(cs) - 1; i >= 0; i-- {
+			if c, ok := cs[i].(*typeDeclarationContext); ok && c.ident == t.Name {
+				return c.structType
 			}
 		}
 	}
-	return -1
+	return nil
 }
 
 type forRangeContext struct {
@@ -627,9 +972,7 @@ func forRangeContextFromRangeStmt(stmt *ast.RangeStmt) *forRangeContext {
 		}
 	}
 	if stmt.X != nil {
-		if ident, ok := stmt.X.(*ast.Ident); ok {
-			iterName = ident.Name
-		}
+		iterName = tableIdentOf(stmt.X)
 	}
 	if keyName == "" || valueName == "" || iterName == "" {
 		return nil
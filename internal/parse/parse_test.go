@@ -1,7 +1,16 @@
 package parse
 
 import (
+	"context"
+	"go/token"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/lusingander/gotip/internal/tip"
 )
@@ -29,7 +38,7 @@ func TestUnresolvedSubTestResolve(t *testing.T) {
 		{
 			name: "expanded names share resolved children",
 			sub: &unresolvedSubTest{
-				name: &selectorSubTestName{cases: []string{"first", "second"}},
+				name: &selectorSubTestName{cases: []testCaseName{{name: "first"}, {name: "second"}}},
 				subs: []*unresolvedSubTest{
 					{name: &literalSubTestName{name: "child"}},
 				},
@@ -55,7 +64,7 @@ func TestUnresolvedSubTestResolve(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			assertEqualSubTests(t, tt.sub.resolve(), tt.want)
+			assertEqualSubTests(t, tt.sub.resolve(token.NewFileSet()), tt.want)
 		})
 	}
 }
@@ -71,10 +80,21 @@ func TestProcessFile(t *testing.T) {
 		{"b", "testdata/foo/b_test.go", wantTestB()},
 		{"c", "testdata/bar/c_test.go", wantTestC()},
 		{"d", "testdata/baz/d_test.go", wantTestD()},
+		{"e", "testdata/qux/e_test.go", wantTestE()},
+		{"f", "testdata/qux/f_test.go", wantTestF()},
+		{"g", "testdata/qux/g_test.go", wantTestG()},
+		{"h", "testdata/qux/h_test.go", wantTestH()},
+		{"k", "testdata/qux/k_test.go", wantTestK()},
+		{"i", "testdata/scope/i_test.go", wantTestI()},
+		{"j", "testdata/scope/j_test.go", wantTestJ()},
+		{"ext", "testdata/scope/ext_test.go", wantTestExt()},
+		{"l", "testdata/suite/l_test.go", wantTestL()},
+		{"n", "testdata/suite/n_test.go", wantTestN()},
+		{"o", "testdata/helper/o_test.go", wantTestO()},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := processFile(tt.filePath, skipSubtests)
+			got, err := processFile(tt.filePath, skipSubtests, newPackageScopes())
 			if err != nil {
 				t.Errorf("ProcessFile(%s) error = %v", tt.filePath, err)
 				return
@@ -84,6 +104,92 @@ func TestProcessFile(t *testing.T) {
 	}
 }
 
+func TestProcessFile_positions(t *testing.T) {
+	got, err := processFile("testdata/foo/a_test.go", false, newPackageScopes())
+	if err != nil {
+		t.Fatalf("ProcessFile() error = %v", err)
+	}
+	tests := []struct {
+		name string
+		got  tip.Position
+		want tip.Position
+	}{
+		{"test function", got[0].Pos, tip.Position{File: "testdata/foo/a_test.go", Line: 15, Column: 1}},
+		{"positional table entry", got[1].Subs[1].Pos, tip.Position{File: "testdata/foo/a_test.go", Line: 33, Column: 3}},
+		{"keyed table entry", got[2].Subs[2].Pos, tip.Position{File: "testdata/foo/a_test.go", Line: 56, Column: 3}},
+		{"map key", got[6].Subs[0].Pos, tip.Position{File: "testdata/foo/a_test.go", Line: 143, Column: 3}},
+		{"unresolved run call", got[7].Subs[0].Pos, tip.Position{File: "testdata/foo/a_test.go", Line: 159, Column: 3}},
+		{"string ident run call", got[9].Subs[1].Pos, tip.Position{File: "testdata/foo/a_test.go", Line: 198, Column: 2}},
+		{"nested run call", got[11].Subs[0].Subs[0].Pos, tip.Position{File: "testdata/foo/a_test.go", Line: 229, Column: 3}},
+	}
+	scopeGot, err := processFile("testdata/scope/i_test.go", false, newPackageScopes())
+	if err != nil {
+		t.Fatalf("ProcessFile() error = %v", err)
+	}
+	tests = append(tests, []struct {
+		name string
+		got  tip.Position
+		want tip.Position
+	}{
+		{"package-level table entry in another file", scopeGot[0].Subs[1].Pos, tip.Position{File: "testdata/scope/tables_test.go", Line: 12, Column: 2}},
+		{"helper function table entry", scopeGot[2].Subs[0].Pos, tip.Position{File: "testdata/scope/tables_test.go", Line: 25, Column: 3}},
+	}...)
+	namedGot, err := processFile("testdata/scope/j_test.go", false, newPackageScopes())
+	if err != nil {
+		t.Fatalf("ProcessFile() error = %v", err)
+	}
+	tests = append(tests, []struct {
+		name string
+		got  tip.Position
+		want tip.Position
+	}{
+		{"pointer table entry", namedGot[1].Subs[0].Pos, tip.Position{File: "testdata/scope/j_test.go", Line: 20, Column: 3}},
+		{"elided pointer table entry", namedGot[1].Subs[1].Pos, tip.Position{File: "testdata/scope/j_test.go", Line: 21, Column: 3}},
+	}...)
+	suiteGot, err := processFile("testdata/suite/l_test.go", false, newPackageScopes())
+	if err != nil {
+		t.Fatalf("ProcessFile() error = %v", err)
+	}
+	tests = append(tests, []struct {
+		name string
+		got  tip.Position
+		want tip.Position
+	}{
+		{"suite method in another file", suiteGot[0].Subs[0].Pos, tip.Position{File: "testdata/suite/m_test.go", Line: 7, Column: 1}},
+		{"suite subtest", suiteGot[0].Subs[1].Subs[0].Pos, tip.Position{File: "testdata/suite/l_test.go", Line: 23, Column: 2}},
+	}...)
+	helperGot, err := processFile("testdata/helper/o_test.go", false, newPackageScopes())
+	if err != nil {
+		t.Fatalf("ProcessFile() error = %v", err)
+	}
+	tests = append(tests, []struct {
+		name string
+		got  tip.Position
+		want tip.Position
+	}{
+		{"table entry passed to helper", helperGot[0].Subs[1].Pos, tip.Position{File: "testdata/helper/o_test.go", Line: 44, Column: 3}},
+		{"run call in helper", helperGot[2].Subs[0].Pos, tip.Position{File: "testdata/helper/o_test.go", Line: 20, Column: 3}},
+	}...)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Errorf("got position = %+v, want %+v", tt.got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProcessFile_fuzzCorpusPositions(t *testing.T) {
+	got, err := processFile("testdata/qux/f_test.go", false, newPackageScopes())
+	if err != nil {
+		t.Fatalf("ProcessFile() error = %v", err)
+	}
+	want := tip.Position{File: "testdata/qux/testdata/fuzz/FuzzReverse/582528ddfad69eb5", Line: 1, Column: 1}
+	if got[0].Subs[0].Pos != want {
+		t.Errorf("got position = %+v, want %+v", got[0].Subs[0].Pos, want)
+	}
+}
+
 func wantTestA() []*tip.TestFunction {
 	return []*tip.TestFunction{
 		{
@@ -225,6 +331,680 @@ func wantTestD() []*tip.TestFunction {
 			Name: "TestValid",
 			Subs: []*tip.SubTest{},
 		},
+		{
+			Name: "BenchmarkValid",
+			Kind: tip.TestKindBenchmark,
+			Subs: []*tip.SubTest{},
+		},
+	}
+}
+
+func wantTestE() []*tip.TestFunction {
+	return []*tip.TestFunction{
+		{
+			Name: "BenchmarkRepeat",
+			Kind: tip.TestKindBenchmark,
+			Subs: []*tip.SubTest{},
+		},
+		{
+			Name: "BenchmarkSubBenchmarks",
+			Kind: tip.TestKindBenchmark,
+			Subs: []*tip.SubTest{
+				{Name: "small", Resolved: true, Subs: []*tip.SubTest{}},
+				{Name: "large", Resolved: true, Subs: []*tip.SubTest{}},
+			},
+		},
+		{
+			Name: "BenchmarkNestedSubBenchmarks",
+			Kind: tip.TestKindBenchmark,
+			Subs: []*tip.SubTest{
+				{
+					Name:     "outer",
+					Resolved: true,
+					Subs: []*tip.SubTest{
+						{Name: "inner", Resolved: true, Subs: []*tip.SubTest{}},
+					},
+				},
+			},
+		},
+		{
+			Name: "TestAlongsideBenchmarks",
+			Subs: []*tip.SubTest{
+				{Name: "case", Resolved: true, Subs: []*tip.SubTest{}},
+			},
+		},
+	}
+}
+
+func wantTestF() []*tip.TestFunction {
+	return []*tip.TestFunction{
+		{
+			Name: "FuzzReverse",
+			Kind: tip.TestKindFuzz,
+			Subs: []*tip.SubTest{
+				{Name: "582528ddfad69eb5", Resolved: true, Subs: []*tip.SubTest{}},
+				{Name: "9a4f5c0e1e5b5a3d", Resolved: true, Subs: []*tip.SubTest{}},
+			},
+		},
+		{
+			Name: "FuzzWithoutCorpus",
+			Kind: tip.TestKindFuzz,
+			Subs: []*tip.SubTest{},
+		},
+	}
+}
+
+func wantTestG() []*tip.TestFunction {
+	return []*tip.TestFunction{
+		{Name: "Example", Kind: tip.TestKindExample, Subs: []*tip.SubTest{}, HasOutput: true},
+		{Name: "ExampleReverse", Kind: tip.TestKindExample, Subs: []*tip.SubTest{}, HasOutput: true},
+		{Name: "ExampleReverse_unordered", Kind: tip.TestKindExample, Subs: []*tip.SubTest{}, HasOutput: true},
+		{Name: "ExampleReverse_emptyOutput", Kind: tip.TestKindExample, Subs: []*tip.SubTest{}, HasOutput: true},
+		{Name: "ExampleReverse_noOutput", Kind: tip.TestKindExample, Subs: []*tip.SubTest{}, HasOutput: false},
+		{Name: "TestBetweenExamples", Subs: []*tip.SubTest{}},
+	}
+}
+
+func wantTestH() []*tip.TestFunction {
+	return []*tip.TestFunction{
+		{
+			Name: "TestSprintfTableNames",
+			Subs: []*tip.SubTest{
+				{Name: "a_1", Resolved: true, Subs: []*tip.SubTest{}},
+				{Name: "b_2", Resolved: true, Subs: []*tip.SubTest{}},
+				{Name: "c_0", Resolved: true, Subs: []*tip.SubTest{}},
+			},
+		},
+		{
+			Name: "TestConcatTableNames",
+			Subs: []*tip.SubTest{
+				{Name: "case-a", Resolved: true, Subs: []*tip.SubTest{}},
+				{Name: "case-b", Resolved: true, Subs: []*tip.SubTest{}},
+			},
+		},
+		{
+			Name: "TestSprintfVerbs",
+			Subs: []*tip.SubTest{
+				{Name: `x_"x"_ff_0.50_0.5_true`, Resolved: true, Subs: []*tip.SubTest{}},
+				{Name: `y_"y"_-1_2.00_2_false`, Resolved: true, Subs: []*tip.SubTest{}},
+			},
+		},
+		{
+			Name: "TestSprintfIndexAndMapKeyNames",
+			Subs: []*tip.SubTest{
+				{Name: "a-1", Resolved: true, Subs: []*tip.SubTest{}},
+				{Name: "b-2", Resolved: true, Subs: []*tip.SubTest{}},
+				{Name: "0-x", Resolved: true, Subs: []*tip.SubTest{}},
+				{Name: "1-y", Resolved: true, Subs: []*tip.SubTest{}},
+			},
+		},
+		{
+			Name: "TestConstantNames",
+			Subs: []*tip.SubTest{
+				{Name: "base-x", Resolved: true, Subs: []*tip.SubTest{}},
+				{Name: "base-2", Resolved: true, Subs: []*tip.SubTest{}},
+			},
+		},
+		{
+			Name: "TestUnresolvableNames",
+			Subs: []*tip.SubTest{
+				{Name: "", Resolved: false, Subs: []*tip.SubTest{}},
+				{Name: "", Resolved: false, Subs: []*tip.SubTest{}},
+			},
+		},
+	}
+}
+
+func wantTestK() []*tip.TestFunction {
+	return []*tip.TestFunction{
+		{
+			Name: "TestNamesWithSpaces",
+			Subs: []*tip.SubTest{
+				{Name: "handles_empty_input", DisplayName: "handles empty input", Resolved: true, Subs: []*tip.SubTest{}},
+				{Name: "tab_and_nbsp", DisplayName: "tab\tand\u00a0nbsp", Resolved: true, Subs: []*tip.SubTest{}},
+				{Name: `bell\a`, DisplayName: "bell\a", Resolved: true, Subs: []*tip.SubTest{}},
+				{Name: "#00", DisplayName: "", Resolved: true, Subs: []*tip.SubTest{}},
+			},
+		},
+		{
+			Name: "TestDuplicateNames",
+			Subs: []*tip.SubTest{
+				{Name: "same", Resolved: true, Subs: []*tip.SubTest{}},
+				{Name: "same#01", DisplayName: "same", Resolved: true, Subs: []*tip.SubTest{}},
+				{Name: "same#01#01", DisplayName: "same#01", Resolved: true, Subs: []*tip.SubTest{}},
+				{Name: "same#02", DisplayName: "same", Resolved: true, Subs: []*tip.SubTest{}},
+				{Name: "#00", Resolved: true, Subs: []*tip.SubTest{}},
+				{Name: "#01", Resolved: true, Subs: []*tip.SubTest{}},
+			},
+		},
+		{
+			Name: "TestDuplicateNestedNames",
+			Subs: []*tip.SubTest{
+				{
+					Name:        "a_b",
+					DisplayName: "a b",
+					Resolved:    true,
+					Subs: []*tip.SubTest{
+						{Name: "x_y", DisplayName: "x y", Resolved: true, Subs: []*tip.SubTest{}},
+						{Name: "x_y#01", DisplayName: "x y", Resolved: true, Subs: []*tip.SubTest{}},
+					},
+				},
+				{
+					Name:        "a_b#01",
+					DisplayName: "a b",
+					Resolved:    true,
+					Subs: []*tip.SubTest{
+						{Name: "x_y", DisplayName: "x y", Resolved: true, Subs: []*tip.SubTest{}},
+						{Name: "x_y#01", DisplayName: "x y", Resolved: true, Subs: []*tip.SubTest{}},
+					},
+				},
+			},
+		},
+	}
+}
+
+func wantTestI() []*tip.TestFunction {
+	return []*tip.TestFunction{
+		{
+			Name: "TestPackageLevelTable",
+			Subs: []*tip.SubTest{
+				{Name: "pkg-p1", Resolved: true, Subs: []*tip.SubTest{}},
+				{Name: "pkg-p2", Resolved: true, Subs: []*tip.SubTest{}},
+			},
+		},
+		{
+			Name: "TestPackageLevelMap",
+			Subs: []*tip.SubTest{
+				{Name: "m1", Resolved: true, Subs: []*tip.SubTest{}},
+				{Name: "m2", Resolved: true, Subs: []*tip.SubTest{}},
+			},
+		},
+		{
+			Name: "TestHelperFuncTable",
+			Subs: []*tip.SubTest{
+				{Name: "h1", Resolved: true, Subs: []*tip.SubTest{}},
+				{Name: "h2", Resolved: true, Subs: []*tip.SubTest{}},
+			},
+		},
+		{
+			Name: "TestHelperFuncAssigned",
+			Subs: []*tip.SubTest{
+				{Name: "h1", Resolved: true, Subs: []*tip.SubTest{}},
+				{Name: "h2", Resolved: true, Subs: []*tip.SubTest{}},
+			},
+		},
+		{
+			Name: "TestLocalShadowsPackageTable",
+			Subs: []*tip.SubTest{
+				{Name: "local", Resolved: true, Subs: []*tip.SubTest{}},
+			},
+		},
+		{
+			Name: "TestNestedUsesPackageTable",
+			Subs: []*tip.SubTest{
+				{
+					Name:     "outer",
+					Resolved: true,
+					Subs: []*tip.SubTest{
+						{Name: "p1", Resolved: true, Subs: []*tip.SubTest{}},
+						{Name: "p2", Resolved: true, Subs: []*tip.SubTest{}},
+					},
+				},
+			},
+		},
+	}
+}
+
+func wantTestJ() []*tip.TestFunction {
+	return []*tip.TestFunction{
+		{
+			Name: "TestNamedTypePositionalFields",
+			Subs: []*tip.SubTest{
+				{Name: "pos1", Resolved: true, Subs: []*tip.SubTest{}},
+				{Name: "pos2", Resolved: true, Subs: []*tip.SubTest{}},
+			},
+		},
+		{
+			Name: "TestPointerElements",
+			Subs: []*tip.SubTest{
+				{Name: "ptr1", Resolved: true, Subs: []*tip.SubTest{}},
+				{Name: "ptr2", Resolved: true, Subs: []*tip.SubTest{}},
+				{Name: "ptr3", Resolved: true, Subs: []*tip.SubTest{}},
+			},
+		},
+		{
+			Name: "TestNamedTypeMapValues",
+			Subs: []*tip.SubTest{
+				{Name: "map1", Resolved: true, Subs: []*tip.SubTest{}},
+				{Name: "map2", Resolved: true, Subs: []*tip.SubTest{}},
+			},
+		},
+		{
+			Name: "TestNamedTypeSprintf",
+			Subs: []*tip.SubTest{
+				{Name: "fmt-1", Resolved: true, Subs: []*tip.SubTest{}},
+				{Name: "fmt-2", Resolved: true, Subs: []*tip.SubTest{}},
+			},
+		},
+		{
+			Name: "TestLocalNamedType",
+			Subs: []*tip.SubTest{
+				{Name: "local1", Resolved: true, Subs: []*tip.SubTest{}},
+				{Name: "local2", Resolved: true, Subs: []*tip.SubTest{}},
+			},
+		},
+	}
+}
+
+func wantTestL() []*tip.TestFunction {
+	return []*tip.TestFunction{
+		{
+			Name: "TestExampleSuite",
+			Subs: []*tip.SubTest{
+				{Name: "TestAnotherFile", Resolved: true, Subs: []*tip.SubTest{}},
+				{
+					Name:     "TestSubtests",
+					Resolved: true,
+					Subs: []*tip.SubTest{
+						{
+							Name:        "first_case",
+							DisplayName: "first case",
+							Resolved:    true,
+							Subs: []*tip.SubTest{
+								{Name: "nested", Resolved: true, Subs: []*tip.SubTest{}},
+							},
+						},
+						{Name: "a", Resolved: true, Subs: []*tip.SubTest{}},
+						{Name: "b", Resolved: true, Subs: []*tip.SubTest{}},
+					},
+				},
+				{Name: "TestValue", Resolved: true, Subs: []*tip.SubTest{}},
+			},
+		},
+		{
+			Name: "TestOtherSuite",
+			Subs: []*tip.SubTest{
+				{Name: "plain", Resolved: true, Subs: []*tip.SubTest{}},
+				{Name: "TestValueReceiver", Resolved: true, Subs: []*tip.SubTest{}},
+			},
+		},
+	}
+}
+
+func wantTestN() []*tip.TestFunction {
+	return []*tip.TestFunction{
+		{
+			Name: "TestAliasedSuite",
+			Subs: []*tip.SubTest{
+				{Name: "TestA", Resolved: true, Subs: []*tip.SubTest{}},
+			},
+		},
+	}
+}
+
+func wantTestO() []*tip.TestFunction {
+	return []*tip.TestFunction{
+		{
+			Name: "TestHelperWithTable",
+			Subs: []*tip.SubTest{
+				{Name: "first", Resolved: true, Subs: []*tip.SubTest{}},
+				{Name: "second", Resolved: true, Subs: []*tip.SubTest{}},
+			},
+		},
+		{
+			Name: "TestHelperWithTableLiteral",
+			Subs: []*tip.SubTest{
+				{Name: "literal", Resolved: true, Subs: []*tip.SubTest{}},
+			},
+		},
+		{
+			Name: "TestHelperWithName",
+			Subs: []*tip.SubTest{
+				{Name: "twice", Resolved: true, Subs: []*tip.SubTest{}},
+				{Name: "direct", Resolved: true, Subs: []*tip.SubTest{}},
+			},
+		},
+		{
+			// helpers are followed up to two levels deep
+			Name: "TestHelperTooDeep",
+			Subs: []*tip.SubTest{},
+		},
+		{
+			Name: "TestRecursiveHelper",
+			Subs: []*tip.SubTest{
+				{
+					Name:     "level",
+					Resolved: true,
+					Subs: []*tip.SubTest{
+						{Name: "level", Resolved: true, Subs: []*tip.SubTest{}},
+					},
+				},
+			},
+		},
+	}
+}
+
+func wantTestExt() []*tip.TestFunction {
+	return []*tip.TestFunction{
+		{
+			Name: "TestExternalPackageTable",
+			Subs: []*tip.SubTest{
+				{Name: "e1", Resolved: true, Subs: []*tip.SubTest{}},
+			},
+		},
+	}
+}
+
+func TestProcessFilesRecursively_buildConstraints(t *testing.T) {
+	tests := []struct {
+		name  string
+		build tip.BuildConfig
+		want  map[string]string
+	}{
+		{
+			name:  "linux",
+			build: tip.BuildConfig{GOOS: "linux", GOARCH: "amd64"},
+			want: map[string]string{
+				"testdata/build/r_test.go": "TestPlain/linux",
+			},
+		},
+		{
+			name:  "windows with tags",
+			build: tip.BuildConfig{GOOS: "windows", GOARCH: "amd64", Tags: []string{"integration"}},
+			want: map[string]string{
+				"testdata/build/r_test.go":             "TestPlain/windows",
+				"testdata/build/r_windows_test.go":     "TestWindows",
+				"testdata/build/r_integration_test.go": "TestIntegration",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ProcessFilesRecursively("testdata/build", nil, false, tt.build, nil)
+			if err != nil {
+				t.Fatalf("ProcessFilesRecursively() error = %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Errorf("got files length = %d, want %d", len(got), len(tt.want))
+			}
+			for path, want := range tt.want {
+				tfs := got[path]
+				if len(tfs) != 1 {
+					t.Errorf("got tests length of %s = %d, want 1", path, len(tfs))
+					continue
+				}
+				name := tfs[0].Name
+				if len(tfs[0].Subs) > 0 {
+					name += "/" + tfs[0].Subs[0].Name
+				}
+				if name != want {
+					t.Errorf("got test of %s = %s, want %s", path, name, want)
+				}
+			}
+		})
+	}
+}
+
+func TestProcessFilesRecursively_cache(t *testing.T) {
+	dir := t.TempDir()
+	writeFile := func(name, content string, modTime time.Time) {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	modTime := time.Now().Add(-time.Hour)
+	writeFile("a_test.go", "package a\n\nimport \"testing\"\n\nfunc TestA(t *testing.T) {\n\tt.Run(name, func(t *testing.T) {})\n}\n", modTime)
+	writeFile("a.go", "package a\n\nconst name = \"first\"\n", modTime)
+
+	testPath := filepath.Join(dir, "a_test.go")
+	discover := func(cache *tip.DiscoveryCache) string {
+		t.Helper()
+		got, err := ProcessFilesRecursively(dir, nil, false, tip.BuildConfig{}, cache)
+		if err != nil {
+			t.Fatalf("ProcessFilesRecursively() error = %v", err)
+		}
+		tfs := got[testPath]
+		if len(tfs) != 1 || len(tfs[0].Subs) != 1 {
+			t.Fatalf("got tests = %v, want one test with a subtest", tfs)
+		}
+		return tfs[0].Name + "/" + tfs[0].Subs[0].Name
+	}
+
+	cache := tip.NewDiscoveryCache()
+	if got := discover(cache); got != "TestA/first" {
+		t.Errorf("got %s, want TestA/first", got)
+	}
+	entry, ok := cache.Files[testPath]
+	if !ok {
+		t.Fatal("the file is not cached")
+	}
+
+	// the cached tests are used while the files are unchanged
+	entry.Tests[0].Subs[0].Name = "cached"
+	if got := discover(cache); got != "TestA/cached" {
+		t.Errorf("got %s, want TestA/cached", got)
+	}
+
+	// a change in another file of the package invalidates the cache
+	writeFile("a.go", "package a\n\nconst name = \"second\"\n", modTime.Add(time.Minute))
+	if got := discover(cache); got != "TestA/second" {
+		t.Errorf("got %s, want TestA/second", got)
+	}
+
+	// a seed corpus file added to a fuzz test invalidates the cache
+	writeFile("f_test.go", "package a\n\nimport \"testing\"\n\nfunc FuzzF(f *testing.F) {}\n", modTime)
+	fuzzPath := filepath.Join(dir, "f_test.go")
+	discover(cache)
+	if err := os.MkdirAll(filepath.Join(dir, "testdata", "fuzz", "FuzzF"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	writeFile("testdata/fuzz/FuzzF/seed1", "go test fuzz v1\nint(1)\n", modTime)
+	got, err := ProcessFilesRecursively(dir, nil, false, tip.BuildConfig{}, cache)
+	if err != nil {
+		t.Fatalf("ProcessFilesRecursively() error = %v", err)
+	}
+	if tfs := got[fuzzPath]; len(tfs) != 1 || len(tfs[0].Subs) != 1 || tfs[0].Subs[0].Name != "seed1" {
+		t.Errorf("got tests = %v, want FuzzF with the seed corpus entry", tfs)
+	}
+
+	// the removed files are dropped from the cache
+	if err := os.Remove(fuzzPath); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(testPath); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ProcessFilesRecursively(dir, nil, false, tip.BuildConfig{}, cache); err != nil {
+		t.Fatalf("ProcessFilesRecursively() error = %v", err)
+	}
+	if len(cache.Files) != 0 {
+		t.Errorf("got cached files length = %d, want 0", len(cache.Files))
+	}
+}
+
+func TestDiscoverFilesRecursively_cancel(t *testing.T) {
+	dir := t.TempDir()
+	files := make(map[string]string)
+	for _, pkg := range []string{"a", "b", "c", "d"} {
+		files[pkg+"/"+pkg+"_test.go"] = "package " + pkg + "\n\nimport \"testing\"\n\nfunc TestA(t *testing.T) {}\n"
+	}
+	writeTestFiles(t, dir, files)
+
+	cache := tip.NewDiscoveryCache()
+	stalePath := filepath.Join(dir, "e/e_test.go")
+	cache.Files[stalePath] = &tip.DiscoveryCacheEntry{Key: "stale"}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	results := DiscoverFilesRecursively(ctx, dir, nil, false, tip.BuildConfig{}, cache)
+	first := <-results
+	cancel()
+	for range results {
+		// the channel is closed once the cache is updated
+	}
+
+	for path := range first.Tests {
+		if _, ok := cache.Files[path]; !ok {
+			t.Errorf("%s is not cached, want the packages discovered before canceled to be cached", path)
+		}
+	}
+	// the discovery is not complete, so the files which are not discovered this time are kept
+	if _, ok := cache.Files[stalePath]; !ok {
+		t.Error("the cached file is dropped by the canceled discovery")
+	}
+}
+
+func TestDiscoverFilesRecursively_errors(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"a/a_test.go": "package a\n\nimport \"testing\"\n\nfunc TestA(t *testing.T) {}\n",
+	})
+	// a file which cannot be read
+	if err := os.MkdirAll(filepath.Join(dir, "b"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(dir, "b/missing.go"), filepath.Join(dir, "b/b_test.go")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := make(map[string][]*tip.TestFunction)
+	var errs []error
+	for result := range DiscoverFilesRecursively(context.Background(), dir, nil, false, tip.BuildConfig{}, nil) {
+		maps.Copy(tests, result.Tests)
+		errs = append(errs, result.Errors...)
+	}
+	if tfs := tests[filepath.Join(dir, "a/a_test.go")]; len(tfs) != 1 || tfs[0].Name != "TestA" {
+		t.Errorf("got tests = %v, want TestA in the file without errors", tfs)
+	}
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "b_test.go") {
+		t.Errorf("got errors = %v, want the error of b_test.go", errs)
+	}
+
+	processed, err := ProcessFilesRecursively(dir, nil, false, tip.BuildConfig{}, nil)
+	if err == nil || !strings.Contains(err.Error(), "b_test.go") {
+		t.Errorf("ProcessFilesRecursively() error = %v, want the error of b_test.go", err)
+	}
+	if tfs := processed[filepath.Join(dir, "a/a_test.go")]; len(tfs) != 1 || tfs[0].Name != "TestA" {
+		t.Errorf("ProcessFilesRecursively() tests = %v, want TestA in the file without errors", tfs)
+	}
+}
+
+func TestDiscoverFilesRecursively_syntaxErrors(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"a/a_test.go": "package a\n\nimport \"testing\"\n\nfunc TestA(t *testing.T) {}\n",
+		// a file being edited
+		"b/b_test.go": `package b
+
+import "testing"
+
+func TestB1(t *testing.T) {
+	t.Run("first", func(t *testing.T) {})
+}
+
+func TestB2(t *testing.T) {
+	t.Run("second", func(t *testing.T) {
+		if true {
+	})
+}
+
+func TestB3(t *testing.T) {}
+`,
+	})
+
+	cache := tip.NewDiscoveryCache()
+	result := tip.CollectDiscoveryResults(DiscoverFilesRecursively(context.Background(), dir, nil, false, tip.BuildConfig{}, cache))
+	if len(result.Errors) > 0 {
+		t.Fatalf("CollectDiscoveryResults() errors = %v", result.Errors)
+	}
+
+	aPath := filepath.Join(dir, "a/a_test.go")
+	if tfs := result.Tests[aPath]; len(tfs) != 1 || tfs[0].FileHasErrors {
+		t.Errorf("got tests = %v, want TestA without errors", tfs)
+	}
+	if _, ok := result.Diagnostics[aPath]; ok {
+		t.Error("got diagnostics of the file without errors")
+	}
+
+	bPath := filepath.Join(dir, "b/b_test.go")
+	names := make([]string, 0)
+	for _, tf := range result.Tests[bPath] {
+		if !tf.FileHasErrors {
+			t.Errorf("%s: FileHasErrors = false, want true", tf.Name)
+		}
+		names = append(names, tf.Name)
+	}
+	if want := []string{"TestB1", "TestB2"}; !slices.Equal(names[:min(len(names), 2)], want) {
+		t.Errorf("got tests = %v, want the tests before the error %v", names, want)
+	}
+	diagnostics := result.Diagnostics[bPath]
+	if len(diagnostics) == 0 {
+		t.Fatal("got no diagnostics of the file with errors")
+	}
+	if pos := diagnostics[0].Pos; pos.File != bPath || pos.Line != 12 {
+		t.Errorf("got diagnostic at %v, want %s:12", pos, bPath)
+	}
+
+	// the file with errors is parsed again next time
+	if _, ok := cache.Files[bPath]; ok {
+		t.Error("the file with errors is cached")
+	}
+	if _, ok := cache.Files[aPath]; !ok {
+		t.Error("the file without errors is not cached")
+	}
+}
+
+func writeTestFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestProcessPackagesRecursively(t *testing.T) {
+	if testing.Short() {
+		t.Skip("loading packages runs the go command")
+	}
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go command not found")
+	}
+	got, err := ProcessPackagesRecursively("testdata/types", nil, false, tip.BuildConfig{})
+	if err != nil {
+		t.Fatalf("ProcessPackagesRecursively() error = %v", err)
+	}
+	if _, ok := got["testdata/types/q_test.go"]; ok {
+		t.Error("got tests in the file excluded by build constraints")
+	}
+	assertEqualTests(t, got["testdata/types/p_test.go"], wantTestTypes())
+}
+
+func wantTestTypes() []*tip.TestFunction {
+	return []*tip.TestFunction{
+		{
+			Name: "TestAliased",
+			Kind: tip.TestKindTest,
+			Subs: []*tip.SubTest{
+				{Name: "imported_case", DisplayName: "imported case", Resolved: true, Subs: []*tip.SubTest{}},
+				{Name: "base/local", Resolved: true, Subs: []*tip.SubTest{}},
+			},
+		},
+		{
+			Name: "BenchmarkAliased",
+			Kind: tip.TestKindBenchmark,
+			Subs: []*tip.SubTest{
+				{Name: "bench", Resolved: true, Subs: []*tip.SubTest{}},
+			},
+		},
 	}
 }
 
@@ -239,10 +1019,15 @@ func TestProcessFile_skipSubtests(t *testing.T) {
 		{"b", "testdata/foo/b_test.go", wantSkipSubtestsTestB()},
 		{"c", "testdata/bar/c_test.go", wantSkipSubtestsTestC()},
 		{"d", "testdata/baz/d_test.go", wantSkipSubtestsTestD()},
+		{"e", "testdata/qux/e_test.go", wantSkipSubtestsTestE()},
+		{"f", "testdata/qux/f_test.go", wantSkipSubtestsTestF()},
+		{"g", "testdata/qux/g_test.go", wantTestG()},
+		{"h", "testdata/qux/h_test.go", wantSkipSubtestsTestH()},
+		{"i", "testdata/scope/i_test.go", wantSkipSubtestsTestI()},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := processFile(tt.filePath, skipSubtests)
+			got, err := processFile(tt.filePath, skipSubtests, newPackageScopes())
 			if err != nil {
 				t.Errorf("ProcessFile(%s) error = %v", tt.filePath, err)
 				return
@@ -269,6 +1054,28 @@ func wantSkipSubtestsTestA() []*tip.TestFunction {
 	}
 }
 
+func wantSkipSubtestsTestH() []*tip.TestFunction {
+	return []*tip.TestFunction{
+		{Name: "TestSprintfTableNames", Subs: []*tip.SubTest{}},
+		{Name: "TestConcatTableNames", Subs: []*tip.SubTest{}},
+		{Name: "TestSprintfVerbs", Subs: []*tip.SubTest{}},
+		{Name: "TestSprintfIndexAndMapKeyNames", Subs: []*tip.SubTest{}},
+		{Name: "TestConstantNames", Subs: []*tip.SubTest{}},
+		{Name: "TestUnresolvableNames", Subs: []*tip.SubTest{}},
+	}
+}
+
+func wantSkipSubtestsTestI() []*tip.TestFunction {
+	return []*tip.TestFunction{
+		{Name: "TestPackageLevelTable", Subs: []*tip.SubTest{}},
+		{Name: "TestPackageLevelMap", Subs: []*tip.SubTest{}},
+		{Name: "TestHelperFuncTable", Subs: []*tip.SubTest{}},
+		{Name: "TestHelperFuncAssigned", Subs: []*tip.SubTest{}},
+		{Name: "TestLocalShadowsPackageTable", Subs: []*tip.SubTest{}},
+		{Name: "TestNestedUsesPackageTable", Subs: []*tip.SubTest{}},
+	}
+}
+
 func wantSkipSubtestsTestB() []*tip.TestFunction {
 	return []*tip.TestFunction{{Name: "TestLiteralSubtestsWithHelper", Subs: []*tip.SubTest{}}}
 }
@@ -278,7 +1085,26 @@ func wantSkipSubtestsTestC() []*tip.TestFunction {
 }
 
 func wantSkipSubtestsTestD() []*tip.TestFunction {
-	return []*tip.TestFunction{{Name: "TestValid", Subs: []*tip.SubTest{}}}
+	return []*tip.TestFunction{
+		{Name: "TestValid", Subs: []*tip.SubTest{}},
+		{Name: "BenchmarkValid", Kind: tip.TestKindBenchmark, Subs: []*tip.SubTest{}},
+	}
+}
+
+func wantSkipSubtestsTestE() []*tip.TestFunction {
+	return []*tip.TestFunction{
+		{Name: "BenchmarkRepeat", Kind: tip.TestKindBenchmark, Subs: []*tip.SubTest{}},
+		{Name: "BenchmarkSubBenchmarks", Kind: tip.TestKindBenchmark, Subs: []*tip.SubTest{}},
+		{Name: "BenchmarkNestedSubBenchmarks", Kind: tip.TestKindBenchmark, Subs: []*tip.SubTest{}},
+		{Name: "TestAlongsideBenchmarks", Subs: []*tip.SubTest{}},
+	}
+}
+
+func wantSkipSubtestsTestF() []*tip.TestFunction {
+	return []*tip.TestFunction{
+		{Name: "FuzzReverse", Kind: tip.TestKindFuzz, Subs: []*tip.SubTest{}},
+		{Name: "FuzzWithoutCorpus", Kind: tip.TestKindFuzz, Subs: []*tip.SubTest{}},
+	}
 }
 
 func assertEqualTests(t *testing.T, got, want []*tip.TestFunction) {
@@ -296,6 +1122,14 @@ func assertEqualTest(t *testing.T, got, want *tip.TestFunction) {
 		t.Errorf("got name = %s, want %s", got.Name, want.Name)
 		return
 	}
+	if got.Kind != want.Kind {
+		t.Errorf("got kind = %s, want %s", got.Kind, want.Kind)
+		return
+	}
+	if got.HasOutput != want.HasOutput {
+		t.Errorf("got has output = %t, want %t", got.HasOutput, want.HasOutput)
+		return
+	}
 	assertEqualSubTests(t, got.Subs, want.Subs)
 }
 
@@ -314,6 +1148,10 @@ func assertEqualSubTest(t *testing.T, got, want *tip.SubTest) {
 		t.Errorf("got name = %s, want %s", got.Name, want.Name)
 		return
 	}
+	if got.DisplayName != want.DisplayName {
+		t.Errorf("got display name = %q, want %q", got.DisplayName, want.DisplayName)
+		return
+	}
 	if got.Resolved != want.Resolved {
 		t.Errorf("got resolved = %t, want %t", got.Resolved, want.Resolved)
 		return
@@ -0,0 +1,350 @@
+package parse
+
+import (
+	"errors"
+	"go/ast"
+	"go/build"
+	"go/constant"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/lusingander/gotip/internal/tip"
+)
+
+// packageScopes parses each file once, and holds the package-level declarations of each package,
+// which tests in any file of the package can refer to.
+// It may be used by multiple goroutines, as long as the files of a package are processed by one of them.
+type packageScopes struct {
+	fset *token.FileSet
+	// mu guards files, scopes, and parseErrors
+	mu     sync.Mutex
+	files  map[string]*ast.File
+	scopes map[string]*packageScope
+	// syntax errors of the files, which are parsed partially
+	parseErrors map[string]scanner.ErrorList
+	// whether the packages are loaded with type information
+	typed bool
+	// files which the loaded packages exclude by build constraints
+	ignored map[string]bool
+	// build context the build constraints of the files are evaluated with
+	build *build.Context
+}
+
+func newPackageScopes() *packageScopes {
+	return &packageScopes{
+		fset:        token.NewFileSet(),
+		files:       make(map[string]*ast.File),
+		scopes:      make(map[string]*packageScope),
+		parseErrors: make(map[string]scanner.ErrorList),
+		ignored:     make(map[string]bool),
+		build:       &build.Default,
+	}
+}
+
+// buildContext returns the build context for the build configuration, which defaults to the current environment.
+func buildContext(conf tip.BuildConfig) *build.Context {
+	ctxt := build.Default
+	if conf.GOOS != "" {
+		ctxt.GOOS = conf.GOOS
+	}
+	if conf.GOARCH != "" {
+		ctxt.GOARCH = conf.GOARCH
+	}
+	ctxt.BuildTags = conf.Tags
+	return &ctxt
+}
+
+// excluded reports whether the file is not compiled in the build configuration,
+// by its build constraints, or its name such as foo_windows_test.go.
+func (s *packageScopes) excluded(path string) bool {
+	if s.ignored[filepath.Clean(path)] {
+		return true
+	}
+	match, err := s.build.MatchFile(filepath.Dir(path), filepath.Base(path))
+	if err != nil {
+		// the file is reported when it is parsed
+		return false
+	}
+	return !match
+}
+
+func (s *packageScopes) parseFile(path string) (*ast.File, error) {
+	key := filepath.Clean(path)
+	s.mu.Lock()
+	f, ok := s.files[key]
+	s.mu.Unlock()
+	if ok {
+		return f, nil
+	}
+	f, err := parser.ParseFile(s.fset, path, nil, parser.ParseComments|parser.AllErrors)
+	if f == nil {
+		// the file cannot be read
+		return nil, err
+	}
+	if !s.addParseErrors(path, err) && err != nil {
+		return nil, err
+	}
+	// the partial syntax tree of the file with syntax errors is used to find the tests written so far
+	s.mu.Lock()
+	s.files[key] = f
+	s.mu.Unlock()
+	return f, nil
+}
+
+// addParseErrors records the syntax errors of the file, and reports whether err is syntax errors.
+func (s *packageScopes) addParseErrors(path string, err error) bool {
+	var errs scanner.ErrorList
+	if !errors.As(err, &errs) {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.parseErrors[filepath.Clean(path)] = errs
+	return true
+}
+
+// diagnostics returns the syntax errors of the file.
+func (s *packageScopes) diagnostics(path string) []tip.Diagnostic {
+	s.mu.Lock()
+	errs := s.parseErrors[filepath.Clean(path)]
+	s.mu.Unlock()
+	diagnostics := make([]tip.Diagnostic, 0, len(errs))
+	for _, e := range errs {
+		diagnostics = append(diagnostics, tip.Diagnostic{
+			Pos:     tip.Position{File: e.Pos.Filename, Line: e.Pos.Line, Column: e.Pos.Column},
+			Message: e.Msg,
+		})
+	}
+	return diagnostics
+}
+
+// scopeOf returns the scope of the package the file at path belongs to.
+// The package consists of the files in the same directory with the same package name,
+// so the scope of an external test package (foo_test) does not include the declarations of the package under test.
+func (s *packageScopes) scopeOf(path string, file *ast.File) *packageScope {
+	dir := filepath.Dir(path)
+	key := packageScopeKey(dir, file)
+	s.mu.Lock()
+	scope, ok := s.scopes[key]
+	s.mu.Unlock()
+	if ok {
+		return scope
+	}
+
+	scope = newPackageScope(nil)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		// the file itself is the only known file of the package
+		entries = nil
+		scope.addFile(file)
+	}
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".go") || s.excluded(path) {
+			continue
+		}
+		f, err := s.parseFile(path)
+		if err != nil {
+			// files which cannot be parsed do not prevent discovering tests in the others
+			continue
+		}
+		if f.Name.Name == file.Name.Name {
+			scope.addFile(f)
+		}
+	}
+	s.mu.Lock()
+	s.scopes[key] = scope
+	s.mu.Unlock()
+	return scope
+}
+
+func packageScopeKey(dir string, file *ast.File) string {
+	return filepath.Clean(dir) + " " + file.Name.Name
+}
+
+// packageScope holds the contexts of package-level declarations,
+// which precede the contexts of the declarations in the test function.
+type packageScope struct {
+	contexts []subTestContext
+	// functions which may be helpers starting subtests
+	funcs map[string]*ast.FuncDecl
+	// test methods of testify suites by the receiver type name
+	methods map[string][]*ast.FuncDecl
+	// type information of the package, which is nil unless loaded with --discovery=types
+	info *types.Info
+}
+
+func newPackageScope(info *types.Info) *packageScope {
+	return &packageScope{
+		funcs:   make(map[string]*ast.FuncDecl),
+		methods: make(map[string][]*ast.FuncDecl),
+		info:    info,
+	}
+}
+
+// typeOf returns the type of the expression, or nil if the type information is not available.
+func (s *packageScope) typeOf(expr ast.Expr) types.Type {
+	if s == nil || s.info == nil {
+		return nil
+	}
+	return s.info.TypeOf(expr)
+}
+
+// constant returns the value of the constant expression and the name of its type,
+// which is empty if it is untyped, or "?" if it is not a predeclared type.
+func (s *packageScope) constant(expr ast.Expr) (constant.Value, string, bool) {
+	if s == nil || s.info == nil {
+		return nil, "", false
+	}
+	tv, ok := s.info.Types[expr]
+	if !ok || tv.Value == nil {
+		return nil, "", false
+	}
+	basic, ok := tv.Type.(*types.Basic)
+	switch {
+	case !ok:
+		return tv.Value, "?", true
+	case basic.Info()&types.IsUntyped != 0:
+		return tv.Value, "", true
+	}
+	return tv.Value, basic.Name(), true
+}
+
+func (s *packageScope) addFile(f *ast.File) {
+	for _, decl := range f.Decls {
+		switch d := decl.(type) {
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				// each spec is handled separately since the finders only look for the first table in a declaration
+				stmt := &ast.DeclStmt{Decl: &ast.GenDecl{Tok: d.Tok, Specs: []ast.Spec{spec}}}
+				s.contexts = append(s.contexts, findTypeDeclarationsFromDeclStmt(stmt)...)
+				s.contexts = append(s.contexts, findStringIdentContextsFromDeclStmt(stmt)...)
+				if c := findStructSliceLiteralDeclarationFromDeclStmt(stmt); c != nil {
+					s.contexts = append(s.contexts, c)
+				}
+				if c := findMapLiteralDeclarationFromDeclStmt(stmt); c != nil {
+					s.contexts = append(s.contexts, c)
+				}
+			}
+		case *ast.FuncDecl:
+			if isSuiteTestMethod(d) {
+				name := receiverTypeName(d)
+				s.methods[name] = append(s.methods[name], d)
+				continue
+			}
+			if d.Recv == nil && d.Body != nil {
+				s.funcs[d.Name.Name] = d
+			}
+			if c := findTableFromHelperFunc(d); c != nil {
+				s.contexts = append(s.contexts, c)
+			}
+		}
+	}
+}
+
+// findTableFromHelperFunc returns the table returned by a helper function such as `func cases() []testCase { return ... }`.
+// The table is referred to as `cases()`, which is the name ranging over the call or assigning it refers to.
+func findTableFromHelperFunc(fn *ast.FuncDecl) subTestContext {
+	if fn.Recv != nil || fn.Body == nil || len(fn.Body.List) != 1 {
+		return nil
+	}
+	ret, ok := fn.Body.List[0].(*ast.ReturnStmt)
+	if !ok || len(ret.Results) != 1 {
+		return nil
+	}
+	compLit, ok := ret.Results[0].(*ast.CompositeLit)
+	if !ok {
+		return nil
+	}
+	ident := helperCallIdent(fn.Name.Name)
+	switch compLit.Type.(type) {
+	case *ast.ArrayType:
+		return &structSliceLiteralDeclarationContext{ident: ident, compLit: compLit}
+	case *ast.MapType:
+		return &mapLiteralDeclarationContext{ident: ident, compLit: compLit}
+	}
+	return nil
+}
+
+func helperCallIdent(funcName string) string {
+	return funcName + "()"
+}
+
+// tableIdentOf returns the name of the table the expression refers to,
+// which is either a variable or a call of a helper function returning a table.
+func tableIdentOf(expr ast.Expr) string {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		return e.Name
+	case *ast.CallExpr:
+		if fn, ok := e.Fun.(*ast.Ident); ok {
+			return helperCallIdent(fn.Name)
+		}
+	}
+	return ""
+}
+
+// findTableAlias returns a context for the variable that is assigned a table declared elsewhere,
+// such as `tests := cases()` or `tests := packageLevelTests`.
+func findTableAlias(ident string, value ast.Expr, cs ...subTestContext) subTestContext {
+	tableIdent := tableIdentOf(value)
+	if tableIdent == "" {
+		return nil
+	}
+	for i := len(cs) - 1; i >= 0; i-- {
+		switch c := cs[i].(type) {
+		case *structSliceLiteralDeclarationContext:
+			if c.ident == tableIdent {
+				return &structSliceLiteralDeclarationContext{ident: ident, compLit: c.compLit}
+			}
+		case *mapLiteralDeclarationContext:
+			if c.ident == tableIdent {
+				return &mapLiteralDeclarationContext{ident: ident, compLit: c.compLit}
+			}
+		}
+	}
+	return nil
+}
+
+func findTableAliasesFromAssignStmt(assign *ast.AssignStmt, cs ...subTestContext) []subTestContext {
+	if len(assign.Lhs) != len(assign.Rhs) {
+		return nil
+	}
+	aliases := make([]subTestContext, 0)
+	for i, lhs := range assign.Lhs {
+		ident, ok := lhs.(*ast.Ident)
+		if !ok {
+			continue
+		}
+		if c := findTableAlias(ident.Name, assign.Rhs[i], cs...); c != nil {
+			aliases = append(aliases, c)
+		}
+	}
+	return aliases
+}
+
+func findTableAliasesFromDeclStmt(decl *ast.DeclStmt, cs ...subTestContext) []subTestContext {
+	genDecl, ok := decl.Decl.(*ast.GenDecl)
+	if !ok || genDecl.Tok != token.VAR {
+		return nil
+	}
+	aliases := make([]subTestContext, 0)
+	for _, spec := range genDecl.Specs {
+		valueSpec, ok := spec.(*ast.ValueSpec)
+		if !ok || len(valueSpec.Names) != len(valueSpec.Values) {
+			continue
+		}
+		for i, ident := range valueSpec.Names {
+			if c := findTableAlias(ident.Name, valueSpec.Values[i], cs...); c != nil {
+				aliases = append(aliases, c)
+			}
+		}
+	}
+	return aliases
+}
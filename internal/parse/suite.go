@@ -0,0 +1,133 @@
+package parse
+
+import (
+	"go/ast"
+	"go/token"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const testifySuitePath = "github.com/stretchr/testify/suite"
+
+// importName returns the name the file refers to the imported package by, or an empty string if it is not imported.
+func importName(file *ast.File, importPath string) string {
+	for _, spec := range file.Imports {
+		p, err := strconv.Unquote(spec.Path.Value)
+		if err != nil || p != importPath {
+			continue
+		}
+		if spec.Name == nil {
+			return path.Base(importPath)
+		}
+		if spec.Name.Name == "_" || spec.Name.Name == "." {
+			return ""
+		}
+		return spec.Name.Name
+	}
+	return ""
+}
+
+// findSuiteSubTests returns the test methods of the testify suites run in the test function, such as `suite.Run(t, new(MySuite))`.
+// go test reports each method as a subtest of the test function, and the methods are run in the order of their names.
+func findSuiteSubTests(fn *ast.FuncDecl, suitePkg string, scope *packageScope) []*unresolvedSubTest {
+	if suitePkg == "" {
+		return nil
+	}
+	subs := make([]*unresolvedSubTest, 0)
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok || !isSuiteRunCall(call, suitePkg) {
+			return true
+		}
+		typeName := suiteTypeName(call.Args[1])
+		if typeName == "" {
+			return true
+		}
+		for _, method := range scope.suiteTestMethods(typeName) {
+			subs = append(subs, &unresolvedSubTest{
+				name: &literalSubTestName{name: method.Name.Name},
+				pos:  method.Pos(),
+				subs: findSubTests(method.Body.List, receiverNames(method), scope, 0, scope.contexts...),
+			})
+		}
+		return true
+	})
+	return subs
+}
+
+func isSuiteRunCall(call *ast.CallExpr, suitePkg string) bool {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != "Run" || len(call.Args) != 2 {
+		return false
+	}
+	pkg, ok := sel.X.(*ast.Ident)
+	return ok && pkg.Name == suitePkg
+}
+
+// suiteTypeName returns the name of the suite type from `new(MySuite)` or `&MySuite{...}`.
+func suiteTypeName(expr ast.Expr) string {
+	switch e := expr.(type) {
+	case *ast.CallExpr:
+		fn, ok := e.Fun.(*ast.Ident)
+		if !ok || fn.Name != "new" || len(e.Args) != 1 {
+			return ""
+		}
+		if ident, ok := e.Args[0].(*ast.Ident); ok {
+			return ident.Name
+		}
+	case *ast.UnaryExpr:
+		if e.Op != token.AND {
+			return ""
+		}
+		lit, ok := e.X.(*ast.CompositeLit)
+		if !ok {
+			return ""
+		}
+		if ident, ok := lit.Type.(*ast.Ident); ok {
+			return ident.Name
+		}
+	}
+	return ""
+}
+
+// receiverTypeName returns the name of the receiver type of the method, such as MySuite for `func (s *MySuite) TestA()`.
+func receiverTypeName(fn *ast.FuncDecl) string {
+	if fn.Recv == nil || len(fn.Recv.List) != 1 {
+		return ""
+	}
+	typ := fn.Recv.List[0].Type
+	if star, ok := typ.(*ast.StarExpr); ok {
+		typ = star.X
+	}
+	if ident, ok := typ.(*ast.Ident); ok {
+		return ident.Name
+	}
+	return ""
+}
+
+// receiverNames returns the name of the receiver, which starts subtests with `s.Run(name, func() { ... })` in suite methods.
+func receiverNames(fn *ast.FuncDecl) []string {
+	names := make([]string, 0)
+	for _, name := range fn.Recv.List[0].Names {
+		names = append(names, name.Name)
+	}
+	return names
+}
+
+// isSuiteTestMethod reports whether testify runs the method as a test, which is selected by the `^Test` pattern.
+func isSuiteTestMethod(fn *ast.FuncDecl) bool {
+	return fn.Body != nil &&
+		strings.HasPrefix(fn.Name.Name, "Test") &&
+		fn.Type.Params.NumFields() == 0 &&
+		receiverTypeName(fn) != ""
+}
+
+func (s *packageScope) suiteTestMethods(typeName string) []*ast.FuncDecl {
+	methods := slices.Clone(s.methods[typeName])
+	slices.SortFunc(methods, func(a, b *ast.FuncDecl) int {
+		return strings.Compare(a.Name.Name, b.Name.Name)
+	})
+	return methods
+}
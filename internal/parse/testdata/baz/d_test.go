@@ -17,3 +17,9 @@ func TestWrongArity(t *testing.T, x int) {}
 func TestWithB(b *testing.B) {}
 
 func (s *Suite) TestMethod(t *testing.T) {}
+
+func BenchmarkValid(b *testing.B) {}
+
+func Benchmarkhelper(b *testing.B) {}
+
+func BenchmarkWithT(t *testing.T) {}
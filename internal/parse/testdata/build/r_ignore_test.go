@@ -0,0 +1,7 @@
+//go:build ignore
+
+package build
+
+import "testing"
+
+func TestIgnored(t *testing.T) {}
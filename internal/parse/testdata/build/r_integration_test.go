@@ -0,0 +1,7 @@
+//go:build integration
+
+package build
+
+import "testing"
+
+func TestIntegration(t *testing.T) {}
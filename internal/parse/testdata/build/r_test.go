@@ -0,0 +1,7 @@
+package build
+
+import "testing"
+
+func TestPlain(t *testing.T) {
+	t.Run(platform, func(t *testing.T) {})
+}
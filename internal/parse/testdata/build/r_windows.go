@@ -0,0 +1,3 @@
+package build
+
+const platform = "windows"
@@ -0,0 +1,5 @@
+package build
+
+import "testing"
+
+func TestWindows(t *testing.T) {}
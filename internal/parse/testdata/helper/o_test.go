@@ -0,0 +1,66 @@
+package helper
+
+import "testing"
+
+type testCase struct {
+	name string
+	in   int
+}
+
+func runCases(t *testing.T, cases []testCase) {
+	t.Helper()
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) { _ = tc.in })
+	}
+}
+
+func runNamed(tb testing.TB, name string) {
+	tb.Helper()
+	if t, ok := tb.(*testing.T); ok {
+		t.Run(name, func(t *testing.T) {})
+	}
+}
+
+func runTwice(t *testing.T, name string) {
+	runNamed(t, name)
+}
+
+func runThrice(t *testing.T) {
+	runTwice(t, "too deep")
+}
+
+func recursive(t *testing.T, n int) {
+	if n == 0 {
+		return
+	}
+	t.Run("level", func(t *testing.T) {
+		recursive(t, n-1)
+	})
+}
+
+func TestHelperWithTable(t *testing.T) {
+	cases := []testCase{
+		{"first", 1},
+		{"second", 2},
+	}
+	runCases(t, cases)
+}
+
+func TestHelperWithTableLiteral(t *testing.T) {
+	runCases(t, []testCase{
+		{name: "literal", in: 1},
+	})
+}
+
+func TestHelperWithName(t *testing.T) {
+	runTwice(t, "twice")
+	runNamed(t, "direct")
+}
+
+func TestHelperTooDeep(t *testing.T) {
+	runThrice(t)
+}
+
+func TestRecursiveHelper(t *testing.T) {
+	recursive(t, 3)
+}
@@ -0,0 +1,40 @@
+package qux
+
+import (
+	"strings"
+	"testing"
+)
+
+func BenchmarkRepeat(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		strings.Repeat("a", 10)
+	}
+}
+
+func BenchmarkSubBenchmarks(b *testing.B) {
+	sizes := []struct {
+		name string
+		n    int
+	}{
+		{"small", 10},
+		{"large", 1000},
+	}
+
+	for _, size := range sizes {
+		b.Run(size.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				strings.Repeat("a", size.n)
+			}
+		})
+	}
+}
+
+func BenchmarkNestedSubBenchmarks(b *testing.B) {
+	b.Run("outer", func(b *testing.B) {
+		b.Run("inner", func(b *testing.B) {})
+	})
+}
+
+func TestAlongsideBenchmarks(t *testing.T) {
+	t.Run("case", func(t *testing.T) {})
+}
@@ -0,0 +1,26 @@
+package qux
+
+import "testing"
+
+func reverse(s string) string {
+	rs := []rune(s)
+	for i, j := 0, len(rs)-1; i < j; i, j = i+1, j-1 {
+		rs[i], rs[j] = rs[j], rs[i]
+	}
+	return string(rs)
+}
+
+func FuzzReverse(f *testing.F) {
+	f.Add("hello")
+	f.Fuzz(func(t *testing.T, s string) {
+		if reverse(reverse(s)) != s {
+			t.Errorf("double reverse of %q changed it", s)
+		}
+	})
+}
+
+func FuzzWithoutCorpus(f *testing.F) {
+	f.Fuzz(func(t *testing.T, b []byte) {})
+}
+
+func FuzzWithT(t *testing.T) {}
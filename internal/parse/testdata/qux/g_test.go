@@ -0,0 +1,43 @@
+package qux
+
+import (
+	"fmt"
+	"testing"
+)
+
+func Example() {
+	fmt.Println("package")
+	// Output: package
+}
+
+func ExampleReverse() {
+	fmt.Println(reverse("abc"))
+	// Output:
+	// cba
+}
+
+func ExampleReverse_unordered() {
+	fmt.Println("a")
+	fmt.Println("b")
+	// Unordered output:
+	// b
+	// a
+}
+
+func ExampleReverse_emptyOutput() {
+	// Output:
+}
+
+func ExampleReverse_noOutput() {
+	fmt.Println(reverse("abc"))
+}
+
+func Examplelowercase() {
+	// Output:
+}
+
+func ExampleWithArg(t *testing.T) {
+	// Output:
+}
+
+func TestBetweenExamples(t *testing.T) {}
@@ -0,0 +1,84 @@
+package qux
+
+import (
+	"fmt"
+	"strconv"
+	"testing"
+)
+
+func TestSprintfTableNames(t *testing.T) {
+	tests := []struct {
+		name string
+		n    int
+	}{
+		{"a", 1},
+		{name: "b", n: 2},
+		{name: "c"},
+	}
+	for _, tc := range tests {
+		t.Run(fmt.Sprintf("%s_%d", tc.name, tc.n), func(t *testing.T) {})
+	}
+}
+
+func TestConcatTableNames(t *testing.T) {
+	const prefix = "case-"
+	tests := []struct {
+		name string
+	}{
+		{name: "a"},
+		{name: "b"},
+	}
+	for _, tc := range tests {
+		t.Run(prefix+tc.name, func(t *testing.T) {})
+	}
+}
+
+func TestSprintfVerbs(t *testing.T) {
+	tests := []struct {
+		s string
+		n int
+		f float64
+		b bool
+	}{
+		{"x", 255, 0.5, true},
+		{"y", -1, 2, false},
+	}
+	for _, tc := range tests {
+		t.Run(fmt.Sprintf("%v_%q_%x_%.2f_%v_%t", tc.s, tc.s, tc.n, tc.f, tc.f, tc.b), func(t *testing.T) {})
+	}
+}
+
+func TestSprintfIndexAndMapKeyNames(t *testing.T) {
+	tests := map[string]struct {
+		n int
+	}{
+		"a": {n: 1},
+		"b": {n: 2},
+	}
+	for name, tc := range tests {
+		t.Run(fmt.Sprintf("%s-%d", name, tc.n), func(t *testing.T) {})
+	}
+	names := []string{"x", "y"}
+	for i, name := range names {
+		t.Run(fmt.Sprintf("%d-%s", i, name), func(t *testing.T) {})
+	}
+}
+
+func TestConstantNames(t *testing.T) {
+	const base = "base"
+	t.Run(base+"-"+"x", func(t *testing.T) {})
+	t.Run(fmt.Sprintf("%s-%d", base, 2), func(t *testing.T) {})
+}
+
+func TestUnresolvableNames(t *testing.T) {
+	tests := []struct {
+		name string
+		n    int
+	}{
+		{"a", 1},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name+strconv.Itoa(tc.n), func(t *testing.T) {})
+		t.Run(fmt.Sprint(tc.name), func(t *testing.T) {})
+	}
+}
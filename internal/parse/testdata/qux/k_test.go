@@ -0,0 +1,41 @@
+package qux
+
+import "testing"
+
+func TestNamesWithSpaces(t *testing.T) {
+	t.Run("handles empty input", func(t *testing.T) {})
+	t.Run("tab\tand nbsp", func(t *testing.T) {})
+	t.Run("bell\a", func(t *testing.T) {})
+	t.Run("", func(t *testing.T) {})
+}
+
+func TestDuplicateNames(t *testing.T) {
+	tests := []struct {
+		name string
+	}{
+		{"same"},
+		{"same"},
+		{"same#01"},
+		{"same"},
+		{""},
+		{""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {})
+	}
+}
+
+func TestDuplicateNestedNames(t *testing.T) {
+	tests := []struct {
+		name string
+	}{
+		{"a b"},
+		{"a b"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Run("x y", func(t *testing.T) {})
+			t.Run("x y", func(t *testing.T) {})
+		})
+	}
+}
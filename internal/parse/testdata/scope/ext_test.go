@@ -0,0 +1,15 @@
+package scope_test
+
+import "testing"
+
+var externalTests = []struct {
+	name string
+}{
+	{"e1"},
+}
+
+func TestExternalPackageTable(t *testing.T) {
+	for _, tt := range externalTests {
+		t.Run(tt.name, func(t *testing.T) {})
+	}
+}
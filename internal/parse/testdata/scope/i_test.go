@@ -0,0 +1,47 @@
+package scope
+
+import "testing"
+
+func TestPackageLevelTable(t *testing.T) {
+	for _, tt := range packageTests {
+		t.Run(prefix+tt.name, func(t *testing.T) {})
+	}
+}
+
+func TestPackageLevelMap(t *testing.T) {
+	for name, n := range packageCases {
+		t.Run(name, func(t *testing.T) { _ = n })
+	}
+}
+
+func TestHelperFuncTable(t *testing.T) {
+	for _, tt := range helperCases() {
+		t.Run(tt.name, func(t *testing.T) {})
+	}
+}
+
+func TestHelperFuncAssigned(t *testing.T) {
+	tests := helperCases()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {})
+	}
+}
+
+func TestLocalShadowsPackageTable(t *testing.T) {
+	packageTests := []struct {
+		name string
+	}{
+		{"local"},
+	}
+	for _, tt := range packageTests {
+		t.Run(tt.name, func(t *testing.T) {})
+	}
+}
+
+func TestNestedUsesPackageTable(t *testing.T) {
+	t.Run("outer", func(t *testing.T) {
+		for _, tt := range packageTests {
+			t.Run(tt.name, func(t *testing.T) {})
+		}
+	})
+}
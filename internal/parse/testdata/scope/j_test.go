@@ -0,0 +1,61 @@
+package scope
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestNamedTypePositionalFields(t *testing.T) {
+	tests := []testCase{
+		{"pos1", 1},
+		{"pos2", 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) { _ = tt.n })
+	}
+}
+
+func TestPointerElements(t *testing.T) {
+	tests := []*testCase{
+		&testCase{name: "ptr1", n: 1},
+		{name: "ptr2", n: 2},
+		{"ptr3", 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) { _ = tt.n })
+	}
+}
+
+func TestNamedTypeMapValues(t *testing.T) {
+	tests := map[string]testCase{
+		"key1": {"map1", 1},
+		"key2": {name: "map2", n: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) { _ = tt.n })
+	}
+}
+
+func TestNamedTypeSprintf(t *testing.T) {
+	tests := []*testCase{
+		{"fmt", 1},
+		{"fmt", 2},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s-%d", tt.name, tt.n), func(t *testing.T) {})
+	}
+}
+
+func TestLocalNamedType(t *testing.T) {
+	type localCase struct {
+		in   int
+		name string
+	}
+	tests := []localCase{
+		{1, "local1"},
+		{2, "local2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) { _ = tt.in })
+	}
+}
@@ -0,0 +1,3 @@
+package scope
+
+const prefix = "pkg-"
@@ -0,0 +1,28 @@
+package scope
+
+type testCase struct {
+	name string
+	n    int
+}
+
+var packageTests = []struct {
+	name string
+}{
+	{"p1"},
+	{"p2"},
+}
+
+var (
+	unrelated    = 1
+	packageCases = map[string]int{
+		"m1": 1,
+		"m2": 2,
+	}
+)
+
+func helperCases() []testCase {
+	return []testCase{
+		{name: "h1", n: 1},
+		{name: "h2", n: 2},
+	}
+}
@@ -0,0 +1,50 @@
+package suite
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type ExampleSuite struct {
+	suite.Suite
+	value int
+}
+
+func (s *ExampleSuite) SetupTest() {
+	s.value = 1
+}
+
+func (s *ExampleSuite) TestValue() {
+	s.Equal(1, s.value)
+}
+
+func (s *ExampleSuite) TestSubtests() {
+	s.Run("first case", func() {
+		s.Run("nested", func() {})
+	})
+	tests := []struct {
+		name string
+	}{
+		{"a"},
+		{"b"},
+	}
+	for _, tt := range tests {
+		s.Run(tt.name, func() {})
+	}
+}
+
+func (s *ExampleSuite) helper() {}
+
+func TestExampleSuite(t *testing.T) {
+	suite.Run(t, new(ExampleSuite))
+}
+
+type OtherSuite struct {
+	suite.Suite
+}
+
+func TestOtherSuite(t *testing.T) {
+	t.Run("plain", func(t *testing.T) {})
+	suite.Run(t, &OtherSuite{})
+}
@@ -0,0 +1,17 @@
+package suite
+
+import (
+	testifysuite "github.com/stretchr/testify/suite"
+)
+
+func (s *ExampleSuite) TestAnotherFile() {}
+
+func (s OtherSuite) TestValueReceiver() {}
+
+func (s *OtherSuite) TestWithArgs(n int) {}
+
+type AliasedSuite struct {
+	testifysuite.Suite
+}
+
+func (s *AliasedSuite) TestA() {}
@@ -0,0 +1,11 @@
+package suite
+
+import (
+	"testing"
+
+	testifysuite "github.com/stretchr/testify/suite"
+)
+
+func TestAliasedSuite(t *testing.T) {
+	testifysuite.Run(t, new(AliasedSuite))
+}
@@ -0,0 +1,6 @@
+package p
+
+const (
+	base  = "base"
+	local = base + "/local"
+)
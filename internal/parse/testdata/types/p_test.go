@@ -0,0 +1,16 @@
+package p
+
+import (
+	tt "testing"
+
+	"example.com/types/names"
+)
+
+func TestAliased(t *tt.T) {
+	t.Run(names.Prefix+" case", func(t *tt.T) {})
+	t.Run(local, func(t *tt.T) {})
+}
+
+func BenchmarkAliased(b *tt.B) {
+	b.Run("bench", func(b *tt.B) {})
+}
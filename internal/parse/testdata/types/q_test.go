@@ -0,0 +1,7 @@
+//go:build ignore
+
+package p
+
+import "testing"
+
+func TestIgnored(t *testing.T) {}
@@ -0,0 +1,66 @@
+package tip
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+// DiscoveryCache holds the tests discovered in each test file,
+// which are reused on the next launch while the files of the package are unchanged.
+type DiscoveryCache struct {
+	// Version is the version of gotip the tests were discovered by, as the results may differ between versions.
+	Version string
+	Files   map[string]*DiscoveryCacheEntry
+}
+
+type DiscoveryCacheEntry struct {
+	// Key identifies the options and the files of the package the tests were discovered with.
+	Key   string
+	Tests []*TestFunction
+}
+
+func NewDiscoveryCache() *DiscoveryCache {
+	return &DiscoveryCache{
+		Version: AppVersion,
+		Files:   map[string]*DiscoveryCacheEntry{},
+	}
+}
+
+// LoadDiscoveryCache returns the cache of the project.
+// A cache which cannot be read or was written by another version is discarded, as it can always be rebuilt.
+func LoadDiscoveryCache(projectDir string) (*DiscoveryCache, error) {
+	filePath, err := projectStateFilePath(projectDir, "cache")
+	if err != nil {
+		return nil, err
+	}
+
+	bytes, err := os.ReadFile(filePath)
+	if err != nil {
+		return NewDiscoveryCache(), nil
+	}
+
+	var cache DiscoveryCache
+	if err = json.Unmarshal(bytes, &cache); err != nil || cache.Version != AppVersion || cache.Files == nil {
+		return NewDiscoveryCache(), nil
+	}
+	return &cache, nil
+}
+
+func SaveDiscoveryCache(projectDir string, cache *DiscoveryCache) error {
+	filePath, err := projectStateFilePath(projectDir, "cache")
+	if err != nil {
+		return err
+	}
+
+	if err = os.MkdirAll(filepath.Dir(filePath), 0o700); err != nil {
+		return err
+	}
+
+	// the cache is not meant to be read by humans, so it is not indented to keep it small
+	bytes, err := json.Marshal(cache)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filePath, bytes, 0o600)
+}
@@ -0,0 +1,49 @@
+package tip
+
+import "testing"
+
+func TestDiscoveryCache_saveAndLoad(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	projectDir := t.TempDir()
+
+	cache := NewDiscoveryCache()
+	cache.Files["foo/foo_test.go"] = &DiscoveryCacheEntry{
+		Key:   "key",
+		Tests: []*TestFunction{{Name: "TestFoo", Subs: []*SubTest{{Name: "a_b", DisplayName: "a b", Resolved: true}}}},
+	}
+	if err := SaveDiscoveryCache(projectDir, cache); err != nil {
+		t.Fatalf("SaveDiscoveryCache() error = %v", err)
+	}
+
+	got, err := LoadDiscoveryCache(projectDir)
+	if err != nil {
+		t.Fatalf("LoadDiscoveryCache() error = %v", err)
+	}
+	entry, ok := got.Files["foo/foo_test.go"]
+	if !ok {
+		t.Fatal("cached file not found")
+	}
+	if entry.Key != "key" || entry.Tests[0].Name != "TestFoo" || entry.Tests[0].Subs[0].DisplayName != "a b" {
+		t.Errorf("got entry = %+v, want the saved one", entry)
+	}
+}
+
+func TestDiscoveryCache_discardOtherVersion(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	projectDir := t.TempDir()
+
+	cache := NewDiscoveryCache()
+	cache.Version = "0.0.0"
+	cache.Files["foo/foo_test.go"] = &DiscoveryCacheEntry{Key: "key"}
+	if err := SaveDiscoveryCache(projectDir, cache); err != nil {
+		t.Fatalf("SaveDiscoveryCache() error = %v", err)
+	}
+
+	got, err := LoadDiscoveryCache(projectDir)
+	if err != nil {
+		t.Fatalf("LoadDiscoveryCache() error = %v", err)
+	}
+	if got.Version != AppVersion || len(got.Files) != 0 {
+		t.Errorf("got cache = %+v, want an empty cache of the current version", got)
+	}
+}
@@ -3,6 +3,7 @@ package tip
 import (
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/BurntSushi/toml"
 )
@@ -12,12 +13,16 @@ const (
 
 	defaultHistoryLimit = 100
 	defaultDateFormat   = "2006-01-02 15:04:05"
+	defaultFuzzTime     = "30s"
 )
 
 type Config struct {
 	Command []string      `toml:"command"`
+	Editor  []string      `toml:"editor"`
 	Ignore  []string      `toml:"ignore"`
 	History HistoryConfig `toml:"history"`
+	Fuzz    FuzzConfig    `toml:"fuzz"`
+	Build   BuildConfig   `toml:"build"`
 }
 
 type HistoryConfig struct {
@@ -25,14 +30,57 @@ type HistoryConfig struct {
 	DateFormat string `toml:"date_format"`
 }
 
+type FuzzConfig struct {
+	Time string `toml:"time"`
+}
+
+// BuildConfig is the build configuration tests are discovered and run with.
+// Empty values mean the ones of the go command in the current environment.
+type BuildConfig struct {
+	GOOS   string   `toml:"goos"`
+	GOARCH string   `toml:"goarch"`
+	Tags   []string `toml:"tags"`
+}
+
+// BuildFlags returns the flags of the go command for the build tags.
+func (c BuildConfig) BuildFlags() []string {
+	if len(c.Tags) == 0 {
+		return nil
+	}
+	return []string{"-tags=" + strings.Join(c.Tags, ",")}
+}
+
+// Env returns the environment of the go command for GOOS and GOARCH,
+// or nil to use the current environment as it is.
+func (c BuildConfig) Env() []string {
+	if c.GOOS == "" && c.GOARCH == "" {
+		return nil
+	}
+	env := os.Environ()
+	if c.GOOS != "" {
+		env = append(env, "GOOS="+c.GOOS)
+	}
+	if c.GOARCH != "" {
+		env = append(env, "GOARCH="+c.GOARCH)
+	}
+	return env
+}
+
 func defaultConfig() *Config {
 	return &Config{
 		Command: []string{},
+		Editor:  []string{},
 		Ignore:  []string{},
 		History: HistoryConfig{
 			Limit:      defaultHistoryLimit,
 			DateFormat: defaultDateFormat,
 		},
+		Fuzz: FuzzConfig{
+			Time: defaultFuzzTime,
+		},
+		Build: BuildConfig{
+			Tags: []string{},
+		},
 	}
 }
 
@@ -36,13 +36,27 @@ func FilterHistoriesByPackages(histories *Histories, packages []string) *Histori
 		Histories:  make([]*History, 0, len(histories.Histories)),
 	}
 	for _, history := range histories.Histories {
-		if _, ok := packageSet[historyPackageName(history)]; ok {
+		if historyInPackages(history, packageSet) {
 			filtered.Histories = append(filtered.Histories, history)
 		}
 	}
 	return filtered
 }
 
+// historyInPackages reports whether the history refers to any of the packages.
+func historyInPackages(history *History, packageSet map[string]struct{}) bool {
+	if !history.IsSet() {
+		_, ok := packageSet[historyPackageName(history)]
+		return ok
+	}
+	for _, target := range history.Targets {
+		if _, ok := packageSet[normalizePackageName(target.PackageName)]; ok {
+			return true
+		}
+	}
+	return false
+}
+
 func historyPackageName(history *History) string {
 	if history.PackageName != "" {
 		return normalizePackageName(history.PackageName)
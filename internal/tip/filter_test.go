@@ -140,3 +140,31 @@ func TestFilterHistoriesByPackages_usesPathWhenPackageNameIsEmpty(t *testing.T)
 		t.Fatalf("filtered histories len = %d, want 1", len(got.Histories))
 	}
 }
+
+func TestFilterHistoriesByPackages_matchesAnyTargetOfSet(t *testing.T) {
+	histories := &Histories{
+		Histories: []*History{
+			{
+				Targets: []*Target{
+					NewTarget("./internal/tip/model_test.go", "TestA", TestKindTest, false),
+					NewTarget("./internal/parse/parse_test.go", "TestB", TestKindTest, false),
+				},
+			},
+			{
+				Targets: []*Target{
+					NewTarget("./internal/tip/model_test.go", "TestA", TestKindTest, false),
+					NewTarget("./internal/ui/app_test.go", "TestC", TestKindTest, false),
+				},
+			},
+		},
+	}
+
+	got := FilterHistoriesByPackages(histories, []string{"internal/parse"})
+
+	if len(got.Histories) != 1 {
+		t.Fatalf("filtered histories len = %d, want 1", len(got.Histories))
+	}
+	if got.Histories[0].Targets[1].TestNamePattern != "TestB" {
+		t.Errorf("filtered history = %+v, want the set with TestB", got.Histories[0])
+	}
+}
@@ -27,12 +27,31 @@ func newHistories(projectDir string) (*Histories, error) {
 }
 
 func (h *Histories) Add(target *Target, limit int) {
-	history := &History{
-		Path:            target.Path,
-		PackageName:     target.PackageName,
-		TestNamePattern: target.TestNamePattern,
-		IsPrefix:        target.IsPrefix,
-		RunAt:           time.Now(),
+	h.AddSet([]*Target{target}, limit)
+}
+
+// AddSet adds the targets which were run together as a single history.
+func (h *Histories) AddSet(targets []*Target, limit int) {
+	var history *History
+	switch len(targets) {
+	case 0:
+		return
+	case 1:
+		target := targets[0]
+		history = &History{
+			Path:            target.Path,
+			PackageName:     target.PackageName,
+			TestNamePattern: target.TestNamePattern,
+			IsPrefix:        target.IsPrefix,
+			Kind:            target.Kind,
+			IsFuzzing:       target.IsFuzzing,
+			RunAt:           time.Now(),
+		}
+	default:
+		history = &History{
+			Targets: cloneTargets(targets),
+			RunAt:   time.Now(),
+		}
 	}
 
 	// Remove existing history if it refers to the same test to avoid duplicates
@@ -51,14 +70,28 @@ type History struct {
 	PackageName     string
 	TestNamePattern string
 	IsPrefix        bool
-	RunAt           time.Time
+	Kind            TestKind
+	IsFuzzing       bool
+	// Targets holds the targets if more than one target was run together.
+	// The other fields describing a target are empty in that case.
+	Targets []*Target `json:",omitempty"`
+	RunAt   time.Time
 }
 
 func (h *History) referToSameHistory(other *History) bool {
 	return h.Path == other.Path &&
 		h.PackageName == other.PackageName &&
 		h.TestNamePattern == other.TestNamePattern &&
-		h.IsPrefix == other.IsPrefix
+		h.IsPrefix == other.IsPrefix &&
+		h.Kind == other.Kind &&
+		h.IsFuzzing == other.IsFuzzing &&
+		slices.EqualFunc(h.Targets, other.Targets, func(a, b *Target) bool {
+			return *a == *b
+		})
+}
+
+func (h *History) IsSet() bool {
+	return len(h.Targets) > 0
 }
 
 func (h *History) ToTarget() *Target {
@@ -67,11 +100,30 @@ func (h *History) ToTarget() *Target {
 		PackageName:     h.PackageName,
 		TestNamePattern: h.TestNamePattern,
 		IsPrefix:        h.IsPrefix,
+		Kind:            h.Kind,
+		IsFuzzing:       h.IsFuzzing,
+	}
+}
+
+// ToTargets returns all targets of the history, which may be a set of targets.
+func (h *History) ToTargets() []*Target {
+	if h.IsSet() {
+		return cloneTargets(h.Targets)
+	}
+	return []*Target{h.ToTarget()}
+}
+
+func cloneTargets(targets []*Target) []*Target {
+	cloned := make([]*Target, 0, len(targets))
+	for _, t := range targets {
+		c := *t
+		cloned = append(cloned, &c)
 	}
+	return cloned
 }
 
 func LoadHistories(projectDir string) (*Histories, error) {
-	filePath, err := projectHistoriesFilePath(projectDir)
+	filePath, err := projectStateFilePath(projectDir, "history")
 	if err != nil {
 		return nil, err
 	}
@@ -93,7 +145,7 @@ func LoadHistories(projectDir string) (*Histories, error) {
 }
 
 func SaveHistories(projectDir string, histories *Histories) error {
-	filePath, err := projectHistoriesFilePath(projectDir)
+	filePath, err := projectStateFilePath(projectDir, "history")
 	if err != nil {
 		return err
 	}
@@ -109,19 +161,20 @@ func SaveHistories(projectDir string, histories *Histories) error {
 	return os.WriteFile(filePath, bytes, 0o600)
 }
 
-func projectHistoriesFilePath(projectDir string) (string, error) {
+// projectStateFilePath returns the path of the project's file in the state directory for the given kind of state.
+func projectStateFilePath(projectDir, kind string) (string, error) {
 	home, err := os.UserHomeDir()
 	if err != nil {
 		return "", err
 	}
-	fileName, err := projectHistoriesFileName(projectDir)
+	fileName, err := projectStateFileName(projectDir)
 	if err != nil {
 		return "", err
 	}
-	return filepath.Join(home, ".local", "state", "gotip", "history", fileName), nil
+	return filepath.Join(home, ".local", "state", "gotip", kind, fileName), nil
 }
 
-func projectHistoriesFileName(projectDir string) (string, error) {
+func projectStateFileName(projectDir string) (string, error) {
 	absDir, err := filepath.Abs(projectDir)
 	if err != nil {
 		return "", err
@@ -11,10 +11,10 @@ func TestHistoriesAdd(t *testing.T) {
 		Histories:  []*History{},
 	}
 
-	sut.Add(NewTarget("./foo/foo_test.go", "TestA", false), 10)
-	sut.Add(NewTarget("./foo/foo_test.go", "TestB", false), 10)
-	sut.Add(NewTarget("./bar/bar_test.go", "TestC", false), 10)
-	sut.Add(NewTarget("./bar/bar_test.go", "TestD", false), 10)
+	sut.Add(NewTarget("./foo/foo_test.go", "TestA", TestKindTest, false), 10)
+	sut.Add(NewTarget("./foo/foo_test.go", "TestB", TestKindTest, false), 10)
+	sut.Add(NewTarget("./bar/bar_test.go", "TestC", TestKindTest, false), 10)
+	sut.Add(NewTarget("./bar/bar_test.go", "TestD", TestKindTest, false), 10)
 
 	assertHistoriesCount(t, sut, 4)
 	assertHistoryTestName(t, sut.Histories[0], "TestD")
@@ -22,28 +22,28 @@ func TestHistoriesAdd(t *testing.T) {
 	assertHistoryTestName(t, sut.Histories[2], "TestB")
 	assertHistoryTestName(t, sut.Histories[3], "TestA")
 
-	sut.Add(NewTarget("./foo/foo_test.go", "TestE", false), 3)
+	sut.Add(NewTarget("./foo/foo_test.go", "TestE", TestKindTest, false), 3)
 
 	assertHistoriesCount(t, sut, 3)
 	assertHistoryTestName(t, sut.Histories[0], "TestE")
 	assertHistoryTestName(t, sut.Histories[1], "TestD")
 	assertHistoryTestName(t, sut.Histories[2], "TestC")
 
-	sut.Add(NewTarget("./bar/bar_test.go", "TestF", false), 3)
+	sut.Add(NewTarget("./bar/bar_test.go", "TestF", TestKindTest, false), 3)
 
 	assertHistoriesCount(t, sut, 3)
 	assertHistoryTestName(t, sut.Histories[0], "TestF")
 	assertHistoryTestName(t, sut.Histories[1], "TestE")
 	assertHistoryTestName(t, sut.Histories[2], "TestD")
 
-	sut.Add(NewTarget("./foo/foo_test.go", "TestE", false), 3)
+	sut.Add(NewTarget("./foo/foo_test.go", "TestE", TestKindTest, false), 3)
 
 	assertHistoriesCount(t, sut, 3)
 	assertHistoryTestName(t, sut.Histories[0], "TestE")
 	assertHistoryTestName(t, sut.Histories[1], "TestF")
 	assertHistoryTestName(t, sut.Histories[2], "TestD")
 
-	sut.Add(NewTarget("./foo/foo_test.go", "TestE", false), 5)
+	sut.Add(NewTarget("./foo/foo_test.go", "TestE", TestKindTest, false), 5)
 
 	assertHistoriesCount(t, sut, 3)
 	assertHistoryTestName(t, sut.Histories[0], "TestE")
@@ -51,6 +51,36 @@ func TestHistoriesAdd(t *testing.T) {
 	assertHistoryTestName(t, sut.Histories[2], "TestD")
 }
 
+func TestHistoriesAddSet(t *testing.T) {
+	sut := &Histories{
+		ProjectDir: "/path/to/project",
+		Histories:  []*History{},
+	}
+
+	set := []*Target{
+		NewTarget("./foo/foo_test.go", "TestA", TestKindTest, false),
+		NewTarget("./bar/bar_test.go", "TestB", TestKindTest, false),
+	}
+	sut.AddSet(set, 10)
+	sut.AddSet(set[:1], 10)
+	sut.AddSet(set, 10)
+
+	assertHistoriesCount(t, sut, 2)
+	if !sut.Histories[0].IsSet() {
+		t.Fatalf("want the latest history to be a set")
+	}
+	assertHistoryTestName(t, sut.Histories[1], "TestA")
+
+	targets := sut.Histories[0].ToTargets()
+	if len(targets) != 2 || *targets[0] != *set[0] || *targets[1] != *set[1] {
+		t.Errorf("ToTargets() = %+v, want %+v", targets, set)
+	}
+	targets[0].DropLastSegment()
+	if sut.Histories[0].Targets[0].TestNamePattern != "TestA" {
+		t.Errorf("targets of the history are modified via ToTargets()")
+	}
+}
+
 func assertHistoriesCount(t *testing.T, histories *Histories, wantCount int) {
 	if len(histories.Histories) != wantCount {
 		t.Errorf("want %d histories, got %d", wantCount, len(histories.Histories))
@@ -132,6 +162,18 @@ func TestHistoryReferToSameHistory(t *testing.T) {
 			},
 			want: false,
 		},
+		{
+			name: "different kind",
+			other: &History{
+				Path:            "./foo/foo_test.go",
+				PackageName:     "foo",
+				TestNamePattern: "TestA",
+				IsPrefix:        false,
+				Kind:            TestKindBenchmark,
+				RunAt:           time.Date(2025, 7, 20, 12, 0, 0, 0, time.UTC),
+			},
+			want: false,
+		},
 	}
 
 	for _, tt := range tests {
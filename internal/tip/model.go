@@ -1,19 +1,120 @@
 package tip
 
 import (
+	"fmt"
+	"maps"
 	"path/filepath"
 	"strings"
 )
 
+type TestKind int
+
+const (
+	TestKindTest TestKind = iota
+	TestKindBenchmark
+	TestKindFuzz
+	TestKindExample
+)
+
+func (k TestKind) String() string {
+	switch k {
+	case TestKindTest:
+		return "test"
+	case TestKindBenchmark:
+		return "benchmark"
+	case TestKindFuzz:
+		return "fuzz"
+	case TestKindExample:
+		return "example"
+	default:
+		return "unknown"
+	}
+}
+
 type TestFunction struct {
 	Name string
+	Kind TestKind
 	Subs []*SubTest
+	Pos  Position
+	// HasOutput reports whether an example has an output comment.
+	// Examples without it are compiled but not executed by go test.
+	HasOutput bool
+	// FileHasErrors reports whether the file has syntax errors.
+	// The test is found in the partially parsed file, so it may be incomplete and fail to compile.
+	FileHasErrors bool
+}
+
+// Diagnostic is a problem found in a test file, such as a syntax error.
+type Diagnostic struct {
+	Pos     Position
+	Message string
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s:%d:%d: %s", d.Pos.File, d.Pos.Line, d.Pos.Column, d.Message)
+}
+
+// DiscoveryResult is the tests discovered in the test files of a package,
+// with the errors of the files which could not be processed.
+type DiscoveryResult struct {
+	// Dir is the directory of the package, which is empty for the errors not related to a package.
+	Dir   string
+	Tests map[string][]*TestFunction
+	// Diagnostics are the problems of the files by path, which do not prevent discovering tests in them.
+	Diagnostics map[string][]Diagnostic
+	Errors      []error
+}
+
+// CollectDiscoveryResults merges all results sent to the channel until it is closed, including all errors reported.
+func CollectDiscoveryResults(results <-chan *DiscoveryResult) *DiscoveryResult {
+	merged := &DiscoveryResult{
+		Tests:       make(map[string][]*TestFunction),
+		Diagnostics: make(map[string][]Diagnostic),
+	}
+	for result := range results {
+		maps.Copy(merged.Tests, result.Tests)
+		maps.Copy(merged.Diagnostics, result.Diagnostics)
+		merged.Errors = append(merged.Errors, result.Errors...)
+	}
+	return merged
 }
 
 type SubTest struct {
-	Name     string
-	Resolved bool
-	Subs     []*SubTest
+	// Name is the name go test reports, which is rewritten from the name in the source
+	// (e.g. spaces become underscores, and duplicates get #NN suffixes).
+	Name string
+	// DisplayName is the name as written in the source, or empty if it is the same as Name.
+	DisplayName string
+	Resolved    bool
+	Subs        []*SubTest
+	// Pos is the position of the Run call, or of the table entry for table-driven tests.
+	Pos Position
+}
+
+// NameForView returns the name as written in the source.
+func (s *SubTest) NameForView() string {
+	if s.DisplayName != "" {
+		return s.DisplayName
+	}
+	return s.Name
+}
+
+// Position is a source position. Line and Column are 1-based, and zero if unknown.
+type Position struct {
+	File   string
+	Line   int
+	Column int
+}
+
+func (p Position) IsValid() bool {
+	return p.Line > 0
+}
+
+func (p Position) String() string {
+	if !p.IsValid() {
+		return p.File
+	}
+	return fmt.Sprintf("%s:%d", p.File, p.Line)
 }
 
 type Target struct {
@@ -21,9 +122,11 @@ type Target struct {
 	PackageName     string
 	TestNamePattern string
 	IsPrefix        bool
+	Kind            TestKind
+	IsFuzzing       bool
 }
 
-func NewTarget(path, name string, isUnresolved bool) *Target {
+func NewTarget(path, name string, kind TestKind, isUnresolved bool) *Target {
 	if isUnresolved {
 		name = strings.TrimSuffix(name, UnresolvedTestCaseName)
 	}
@@ -32,6 +135,7 @@ func NewTarget(path, name string, isUnresolved bool) *Target {
 		PackageName:     relativePathToPackageName(path),
 		TestNamePattern: name,
 		IsPrefix:        isUnresolved,
+		Kind:            kind,
 	}
 }
 
@@ -44,6 +148,26 @@ func relativePathToPackageName(path string) string {
 	return name
 }
 
+// FuzzTarget returns a target that runs the fuzzing engine on the fuzz test the target belongs to.
+// It returns nil if the target is not a fuzz test.
+func (t *Target) FuzzTarget() *Target {
+	if t.Kind != TestKindFuzz {
+		return nil
+	}
+	name, _, _ := strings.Cut(t.TestNamePattern, "/")
+	if name == "" {
+		return nil
+	}
+	return &Target{
+		Path:            t.Path,
+		PackageName:     t.PackageName,
+		TestNamePattern: name,
+		IsPrefix:        false,
+		Kind:            t.Kind,
+		IsFuzzing:       true,
+	}
+}
+
 func (t *Target) DropLastSegment() {
 	pattern := strings.TrimSuffix(t.TestNamePattern, "/")
 	if lastSlash := strings.LastIndex(pattern, "/"); lastSlash != -1 {
@@ -45,3 +45,42 @@ func TestDropLastSegment(t *testing.T) {
 		})
 	}
 }
+
+func TestFuzzTarget(t *testing.T) {
+	tests := []struct {
+		name   string
+		target *Target
+		want   *Target
+	}{
+		{
+			name:   "fuzz test",
+			target: NewTarget("./foo/foo_test.go", "FuzzFoo", TestKindFuzz, false),
+			want:   &Target{Path: "./foo/foo_test.go", PackageName: "./foo", TestNamePattern: "FuzzFoo", Kind: TestKindFuzz, IsFuzzing: true},
+		},
+		{
+			name:   "seed corpus entry",
+			target: NewTarget("./foo/foo_test.go", "FuzzFoo/0123abcd", TestKindFuzz, false),
+			want:   &Target{Path: "./foo/foo_test.go", PackageName: "./foo", TestNamePattern: "FuzzFoo", Kind: TestKindFuzz, IsFuzzing: true},
+		},
+		{
+			name:   "not a fuzz test",
+			target: NewTarget("./foo/foo_test.go", "TestFoo", TestKindTest, false),
+			want:   nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.target.FuzzTarget()
+			if tt.want == nil {
+				if got != nil {
+					t.Errorf("expected nil, got %+v", got)
+				}
+				return
+			}
+			if got == nil || *got != *tt.want {
+				t.Errorf("expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package tip
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ModulePath returns the module path declared in go.mod of the project directory.
+// It returns an empty string if there is no go.mod.
+func ModulePath(projectDir string) (string, error) {
+	f, err := os.Open(filepath.Join(projectDir, "go.mod"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		rest, ok := strings.CutPrefix(line, "module")
+		if !ok || rest == "" || (rest[0] != ' ' && rest[0] != '\t') {
+			continue
+		}
+		rest, _, _ = strings.Cut(rest, "//")
+		path := strings.TrimSpace(rest)
+		if unquoted, err := strconv.Unquote(path); err == nil {
+			path = unquoted
+		}
+		return path, nil
+	}
+	return "", scanner.Err()
+}
+
+// DirImportPath returns the import path of the directory, from the module path declared in the nearest go.mod
+// in the directory or its parents, since gotip may be started in a subdirectory of the module.
+// It returns an empty string if there is no go.mod.
+func DirImportPath(dir string) (string, error) {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	moduleDir := absDir
+	for {
+		if _, err := os.Stat(filepath.Join(moduleDir, "go.mod")); err == nil {
+			break
+		}
+		parent := filepath.Dir(moduleDir)
+		if parent == moduleDir {
+			return "", nil
+		}
+		moduleDir = parent
+	}
+
+	modulePath, err := ModulePath(moduleDir)
+	if err != nil || modulePath == "" {
+		return "", err
+	}
+	rel, err := filepath.Rel(moduleDir, absDir)
+	if err != nil {
+		return "", err
+	}
+	return path.Join(modulePath, filepath.ToSlash(rel)), nil
+}
+
+// RelativePackageName converts an import path to a package name relative to the directory of dirImportPath,
+// in the same form as Target.PackageName. Import paths outside the directory are returned as is.
+func RelativePackageName(importPath, dirImportPath string) string {
+	if dirImportPath == "" {
+		return importPath
+	}
+	if importPath == dirImportPath {
+		return "."
+	}
+	if rest, ok := strings.CutPrefix(importPath, dirImportPath+"/"); ok {
+		return "./" + rest
+	}
+	return importPath
+}
@@ -0,0 +1,165 @@
+package tip
+
+import (
+	"encoding/json"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// TestRecord is the latest outcome of a test.
+type TestRecord struct {
+	Status  TestStatus
+	Elapsed time.Duration
+	RunAt   time.Time
+}
+
+type TestRecords struct {
+	ProjectDir string
+	// Records maps "package test" to the latest outcome of the test.
+	Records map[string]*TestRecord
+	// LastFailed holds the failed leaf tests of the last run.
+	LastFailed []*FailedTest
+}
+
+type FailedTest struct {
+	Package string
+	// Name is empty if the package failed without any failed test, such as a build failure.
+	Name string
+}
+
+func newTestRecords(projectDir string) (*TestRecords, error) {
+	absDir, err := filepath.Abs(projectDir)
+	if err != nil {
+		return nil, err
+	}
+	return &TestRecords{
+		ProjectDir: absDir,
+		Records:    map[string]*TestRecord{},
+	}, nil
+}
+
+// Add records the outcome of every test in the result.
+// Existing records are replaced rather than updated, so records already handed out are never modified.
+func (r *TestRecords) Add(result *RunResult) {
+	runAt := time.Now()
+	for _, t := range result.Tests {
+		r.Records[testRecordKey(t.Package, t.Name)] = &TestRecord{
+			Status:  t.Status,
+			Elapsed: t.Elapsed,
+			RunAt:   runAt,
+		}
+	}
+	r.LastFailed = make([]*FailedTest, 0)
+	for _, t := range result.FailedLeaves() {
+		r.LastFailed = append(r.LastFailed, &FailedTest{
+			Package: t.Package,
+			Name:    t.Name,
+		})
+	}
+	for _, p := range result.FailedPackages() {
+		r.LastFailed = append(r.LastFailed, &FailedTest{
+			Package: p.Package,
+		})
+	}
+}
+
+// FailedTargets returns the targets to rerun the failed tests of the last run.
+func (r *TestRecords) FailedTargets() []*Target {
+	if r == nil {
+		return nil
+	}
+	targets := make([]*Target, 0, len(r.LastFailed))
+	for _, t := range r.LastFailed {
+		if t.Name == "" {
+			// all tests in the package
+			targets = append(targets, &Target{
+				PackageName: t.Package,
+				IsPrefix:    true,
+				Kind:        TestKindTest,
+			})
+			continue
+		}
+		targets = append(targets, &Target{
+			PackageName:     t.Package,
+			TestNamePattern: t.Name,
+			Kind:            testKindFromName(t.Name),
+		})
+	}
+	return targets
+}
+
+func testKindFromName(name string) TestKind {
+	switch {
+	case strings.HasPrefix(name, "Benchmark"):
+		return TestKindBenchmark
+	case strings.HasPrefix(name, "Fuzz"):
+		return TestKindFuzz
+	case strings.HasPrefix(name, "Example"):
+		return TestKindExample
+	default:
+		return TestKindTest
+	}
+}
+
+// Get returns the latest outcome of the test the target refers to, or nil if it has never been run.
+// For prefix targets, the outcome of the parent test is returned.
+func (r *TestRecords) Get(target *Target) *TestRecord {
+	if r == nil {
+		return nil
+	}
+	name := strings.TrimSuffix(target.TestNamePattern, "/")
+	if name == "" {
+		return nil
+	}
+	return r.Records[testRecordKey(target.PackageName, name)]
+}
+
+func testRecordKey(packageName, testName string) string {
+	// package names of targets ("./.") and of results (".") differ for the module root
+	return path.Clean(packageName) + " " + testName
+}
+
+func LoadTestRecords(projectDir string) (*TestRecords, error) {
+	filePath, err := projectStateFilePath(projectDir, "result")
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := os.Stat(filePath); err != nil {
+		return newTestRecords(projectDir)
+	}
+
+	bytes, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var records TestRecords
+	if err = json.Unmarshal(bytes, &records); err != nil {
+		return nil, err
+	}
+	if records.Records == nil {
+		records.Records = map[string]*TestRecord{}
+	}
+	return &records, nil
+}
+
+func SaveTestRecords(projectDir string, records *TestRecords) error {
+	filePath, err := projectStateFilePath(projectDir, "result")
+	if err != nil {
+		return err
+	}
+
+	if err = os.MkdirAll(filepath.Dir(filePath), 0o700); err != nil {
+		return err
+	}
+
+	bytes, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filePath, bytes, 0o600)
+}
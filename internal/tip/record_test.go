@@ -0,0 +1,77 @@
+package tip
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTestRecordsGet(t *testing.T) {
+	sut := &TestRecords{Records: map[string]*TestRecord{}}
+	sut.Add(&RunResult{
+		Tests: []*TestResult{
+			{Package: ".", Name: "TestA", Status: TestStatusPass, Elapsed: 10 * time.Millisecond},
+			{Package: "./foo", Name: "TestB/x", Status: TestStatusFail},
+			{Package: "./foo", Name: "TestB", Status: TestStatusFail},
+		},
+	})
+
+	tests := []struct {
+		target *Target
+		want   *TestStatus
+	}{
+		{NewTarget("./a_test.go", "TestA", TestKindTest, false), ptr(TestStatusPass)},
+		{NewTarget("./foo/b_test.go", "TestB/x", TestKindTest, false), ptr(TestStatusFail)},
+		{NewTarget("./foo/b_test.go", "TestB/"+UnresolvedTestCaseName, TestKindTest, true), ptr(TestStatusFail)},
+		{NewTarget("./foo/b_test.go", "TestB/y", TestKindTest, false), nil},
+		{NewTarget("./bar/b_test.go", "TestB", TestKindTest, false), nil},
+		{NewTarget("./foo/b_test.go", "", TestKindTest, true), nil},
+	}
+	for _, tt := range tests {
+		got := sut.Get(tt.target)
+		switch {
+		case tt.want == nil && got != nil:
+			t.Errorf("Get(%s %s) = %+v, want nil", tt.target.PackageName, tt.target.TestNamePattern, got)
+		case tt.want != nil && (got == nil || got.Status != *tt.want):
+			t.Errorf("Get(%s %s) = %+v, want %v", tt.target.PackageName, tt.target.TestNamePattern, got, *tt.want)
+		}
+	}
+
+	// records are replaced instead of updated
+	before := sut.Get(NewTarget("./a_test.go", "TestA", TestKindTest, false))
+	sut.Add(&RunResult{Tests: []*TestResult{{Package: ".", Name: "TestA", Status: TestStatusFail}}})
+	if before.Status != TestStatusPass {
+		t.Errorf("existing record is modified: %+v", before)
+	}
+}
+
+func TestTestRecordsFailedTargets(t *testing.T) {
+	sut := &TestRecords{Records: map[string]*TestRecord{}}
+	sut.Add(&RunResult{
+		Tests: []*TestResult{
+			{Package: "./foo", Name: "TestA/x", Status: TestStatusFail},
+			{Package: "./foo", Name: "TestA", Status: TestStatusFail},
+		},
+		Packages: []*PackageResult{
+			{Package: "./foo", Status: TestStatusFail},
+			// a package which fails to build has no test results
+			{Package: "./bar", Status: TestStatusFail},
+			{Package: "./baz", Status: TestStatusPass},
+		},
+		ExitCode: 1,
+	})
+
+	got := sut.FailedTargets()
+	if len(got) != 2 {
+		t.Fatalf("FailedTargets() len = %d, want 2", len(got))
+	}
+	if got[0].PackageName != "./foo" || got[0].TestNamePattern != "TestA/x" || got[0].IsPrefix {
+		t.Errorf("FailedTargets()[0] = %+v, want ./foo TestA/x", got[0])
+	}
+	if got[1].PackageName != "./bar" || got[1].TestNamePattern != "" || !got[1].IsPrefix {
+		t.Errorf("FailedTargets()[1] = %+v, want all tests in ./bar", got[1])
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
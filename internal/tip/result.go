@@ -0,0 +1,104 @@
+package tip
+
+import (
+	"strings"
+	"time"
+)
+
+type TestStatus int
+
+const (
+	TestStatusPass TestStatus = iota
+	TestStatusFail
+	TestStatusSkip
+)
+
+func (s TestStatus) String() string {
+	switch s {
+	case TestStatusPass:
+		return "pass"
+	case TestStatusFail:
+		return "fail"
+	case TestStatusSkip:
+		return "skip"
+	default:
+		return "unknown"
+	}
+}
+
+// TestResult is the outcome of a test (or subtest) reported by go test -json.
+type TestResult struct {
+	Package string
+	Name    string // full name such as Parent/Sub
+	Status  TestStatus
+	Elapsed time.Duration
+	Output  string
+}
+
+// PackageResult is the outcome of a whole package, which may fail without any failed test (e.g. build errors).
+type PackageResult struct {
+	Package string
+	Status  TestStatus
+	Elapsed time.Duration
+}
+
+type RunResult struct {
+	ExitCode int
+	Tests    []*TestResult
+	Packages []*PackageResult
+}
+
+func (r *RunResult) Count(status TestStatus) int {
+	n := 0
+	for _, t := range r.Tests {
+		if t.Status == status {
+			n++
+		}
+	}
+	return n
+}
+
+// FailedLeaves returns the failed tests which have no failed subtests.
+// A parent test fails whenever one of its subtests fails, so these are the tests that actually need attention.
+func (r *RunResult) FailedLeaves() []*TestResult {
+	leaves := make([]*TestResult, 0)
+	for _, t := range r.Tests {
+		if t.Status != TestStatusFail {
+			continue
+		}
+		if !r.hasFailedSubTest(t) {
+			leaves = append(leaves, t)
+		}
+	}
+	return leaves
+}
+
+func (r *RunResult) hasFailedSubTest(parent *TestResult) bool {
+	for _, t := range r.Tests {
+		if t.Status == TestStatusFail && t.Package == parent.Package && strings.HasPrefix(t.Name, parent.Name+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// FailedPackages returns the failed packages which have no failed tests.
+func (r *RunResult) FailedPackages() []*PackageResult {
+	pkgs := make([]*PackageResult, 0)
+	for _, p := range r.Packages {
+		if p.Status != TestStatusFail {
+			continue
+		}
+		hasFailedTest := false
+		for _, t := range r.Tests {
+			if t.Status == TestStatusFail && t.Package == p.Package {
+				hasFailedTest = true
+				break
+			}
+		}
+		if !hasFailedTest {
+			pkgs = append(pkgs, p)
+		}
+	}
+	return pkgs
+}
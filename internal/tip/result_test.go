@@ -0,0 +1,94 @@
+package tip
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestRunResultFailed(t *testing.T) {
+	result := &RunResult{
+		Tests: []*TestResult{
+			{Package: "./foo", Name: "TestA/x/1", Status: TestStatusFail},
+			{Package: "./foo", Name: "TestA/x", Status: TestStatusFail},
+			{Package: "./foo", Name: "TestA/y", Status: TestStatusFail},
+			{Package: "./foo", Name: "TestA", Status: TestStatusFail},
+			{Package: "./foo", Name: "TestAB", Status: TestStatusFail},
+			{Package: "./bar", Name: "TestA", Status: TestStatusFail},
+			{Package: "./bar", Name: "TestB", Status: TestStatusPass},
+		},
+		Packages: []*PackageResult{
+			{Package: "./foo", Status: TestStatusFail},
+			{Package: "./bar", Status: TestStatusFail},
+			{Package: "./baz", Status: TestStatusFail},
+			{Package: "./qux", Status: TestStatusPass},
+		},
+	}
+
+	var leaves []string
+	for _, l := range result.FailedLeaves() {
+		leaves = append(leaves, l.Package+" "+l.Name)
+	}
+	wantLeaves := []string{"./foo TestA/x/1", "./foo TestA/y", "./foo TestAB", "./bar TestA"}
+	if !reflect.DeepEqual(leaves, wantLeaves) {
+		t.Errorf("FailedLeaves() = %v, want %v", leaves, wantLeaves)
+	}
+
+	pkgs := result.FailedPackages()
+	if len(pkgs) != 1 || pkgs[0].Package != "./baz" {
+		t.Errorf("FailedPackages() = %+v, want ./baz", pkgs)
+	}
+
+	if got := result.Count(TestStatusFail); got != 6 {
+		t.Errorf("Count(fail) = %d, want 6", got)
+	}
+}
+
+func TestRelativePackageName(t *testing.T) {
+	tests := []struct {
+		importPath string
+		modulePath string
+		want       string
+	}{
+		{"example.com/m", "example.com/m", "."},
+		{"example.com/m/foo/bar", "example.com/m", "./foo/bar"},
+		{"example.com/mm", "example.com/m", "example.com/mm"},
+		{"example.com/m/foo", "", "example.com/m/foo"},
+		{"example.com/m/foo/bar", "example.com/m/foo", "./bar"},
+	}
+	for _, tt := range tests {
+		if got := RelativePackageName(tt.importPath, tt.modulePath); got != tt.want {
+			t.Errorf("RelativePackageName(%q, %q) = %q, want %q", tt.importPath, tt.modulePath, got, tt.want)
+		}
+	}
+}
+
+func TestDirImportPath(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "go.mod"), []byte("module example.com/m\n\ngo 1.25\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	sub := filepath.Join(root, "foo", "bar")
+	if err := os.MkdirAll(sub, 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		dir  string
+		want string
+	}{
+		{root, "example.com/m"},
+		// started in a subdirectory of the module
+		{sub, "example.com/m/foo/bar"},
+	}
+	for _, tt := range tests {
+		got, err := DirImportPath(tt.dir)
+		if err != nil {
+			t.Fatalf("DirImportPath(%q) error = %v", tt.dir, err)
+		}
+		if got != tt.want {
+			t.Errorf("DirImportPath(%q) = %q, want %q", tt.dir, got, tt.want)
+		}
+	}
+}
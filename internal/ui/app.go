@@ -3,13 +3,16 @@ package ui
 import (
 	"fmt"
 	"os"
+	"slices"
 	"strings"
 
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/charmbracelet/x/ansi"
+	"github.com/lusingander/gotip/internal/command"
 	"github.com/lusingander/gotip/internal/tip"
 )
 
@@ -17,6 +20,7 @@ var (
 	selectedColor = lipgloss.Color("#00ADD8")
 	cursorColor   = lipgloss.Color("#00ADD8")
 	borderColor   = lipgloss.Color("240")
+	errorColor    = lipgloss.Color("#CE3262")
 
 	helpHeaderColor = lipgloss.Color("#00ADD8")
 	helpKeyColor    = lipgloss.Color("#5DC9E2")
@@ -33,6 +37,7 @@ var (
 			BorderForeground(borderColor)
 
 	footerMsgStyle           = lipgloss.NewStyle()
+	footerErrorMsgStyle      = lipgloss.NewStyle().Foreground(errorColor)
 	footerFilteredStyle      = lipgloss.NewStyle()
 	footerSelectedIndexStyle = lipgloss.NewStyle()
 	footerDividerStyle       = lipgloss.NewStyle().Foreground(borderColor)
@@ -53,6 +58,7 @@ type view int
 const (
 	allView view = iota
 	historyView
+	treeView
 )
 
 func viewFromStr(s string) view {
@@ -61,6 +67,8 @@ func viewFromStr(s string) view {
 		return allView
 	case "history":
 		return historyView
+	case "tree":
+		return treeView
 	default:
 		panic("unknown view type: " + s)
 	}
@@ -72,39 +80,72 @@ const (
 	noneStatusMsgType statusMsgType = iota
 	fuzzyMatchFilteredStatusMsgType
 	exactMatchFilteredStatusMsgType
+	noFailedTestsStatusMsgType
+	errorStatusMsgType
 )
 
+type editorFinishedMsg struct {
+	err error
+}
+
 type model struct {
 	allList         list.Model
 	historyList     list.Model
+	treeList        list.Model
+	treeRoots       []*treeNode
+	treeShowsAll    bool // whether treeList holds all nodes for filtering, instead of the visible ones
+	preview         previewPane
+	showPreview     bool
 	currentView     view
 	showHelp        bool
 	helpOffset      int
+	showErrors      bool
+	errorsOffset    int
 	matchFilterType matchFilterType
 	statusMsgType   statusMsgType
+	statusErr       error
 	w, h            int
+	conf            *tip.Config
+	records         *tip.TestRecords
+	loop            *Loop
+	showResult      bool
+	result          resultPane
+	discovery       discoveryState
 
 	allBeforeSelected     int
 	historyBeforeSelected int
+	treeBeforeSelected    *treeNode
 	tmpTarget             *tip.Target
-	retTarget             *tip.Target
+	retTargets            []*tip.Target
 }
 
-func newModel(allTestItems, historyItems []list.Item, defaultView view, defaultFilterType matchFilterType) model {
+func newModel(allTestItems, historyItems []list.Item, treeRoots []*treeNode, records *tip.TestRecords, conf *tip.Config, loop *Loop, defaultView view, defaultFilterType matchFilterType) model {
 	allList := newList(allTestItems, testCaseItemDelegate{}, defaultFilterType)
 	historyList := newList(historyItems, historyItemDelegate{}, defaultFilterType)
+	treeItems := visibleTreeNodes(treeRoots)
+	treeList := newList(treeItems, treeItemDelegate{}, defaultFilterType)
+	treeList.Filter = treeFilter(treeList.Filter, treeItems)
 	return model{
 		allList:               allList,
 		historyList:           historyList,
+		treeList:              treeList,
+		treeRoots:             treeRoots,
+		preview:               newPreviewPane(),
 		currentView:           defaultView,
 		showHelp:              false,
 		helpOffset:            0,
 		matchFilterType:       defaultFilterType,
 		statusMsgType:         noneStatusMsgType,
+		conf:                  conf,
+		records:               records,
+		loop:                  loop,
+		showResult:            false,
+		result:                newResultPane(),
+		discovery:             newDiscoveryState(nil),
 		allBeforeSelected:     -1,
 		historyBeforeSelected: -1,
 		tmpTarget:             nil,
-		retTarget:             nil,
+		retTargets:            nil,
 	}
 }
 
@@ -118,20 +159,22 @@ func newList(items []list.Item, delegate list.ItemDelegate, defaultFilterType ma
 	l.FilterInput.Prompt = "Filtering: "
 	l.FilterInput.PromptStyle = lipgloss.NewStyle()
 	l.FilterInput.Cursor.Style = lipgloss.NewStyle().Foreground(cursorColor)
-	switch defaultFilterType {
-	case fuzzyMatchFilterType:
-		l.Filter = fuzzyMatchFilter
-	case exactMatchFilterType:
-		l.Filter = exactMatchFilter
-	}
+	l.Filter = matchFilter(defaultFilterType)
 	l.KeyMap.AcceptWhileFiltering = key.NewBinding(key.WithKeys("enter"))
 	return l
 }
 
 func (m *model) setSize(w, h int) {
 	m.w, m.h = w, h
-	m.allList.SetSize(w, h-5)
-	m.historyList.SetSize(w, h-5)
+	listW := w
+	if m.showPreview {
+		listW = w - w/2
+		m.preview.setSize(w/2, h-5)
+	}
+	m.allList.SetSize(listW, h-5)
+	m.historyList.SetSize(listW, h-5)
+	m.treeList.SetSize(listW, h-5)
+	m.result.setSize(w, h-5)
 }
 
 func (m *model) toggleMatchFilter() {
@@ -139,31 +182,38 @@ func (m *model) toggleMatchFilter() {
 	case fuzzyMatchFilterType:
 		m.allList.Filter = exactMatchFilter
 		m.historyList.Filter = exactMatchFilter
+		m.treeList.Filter = treeFilter(exactMatchFilter, m.treeList.Items())
 		m.matchFilterType = exactMatchFilterType
 		m.statusMsgType = exactMatchFilteredStatusMsgType
 	case exactMatchFilterType:
 		m.allList.Filter = fuzzyMatchFilter
 		m.historyList.Filter = fuzzyMatchFilter
+		m.treeList.Filter = treeFilter(fuzzyMatchFilter, m.treeList.Items())
 		m.matchFilterType = fuzzyMatchFilterType
 		m.statusMsgType = fuzzyMatchFilteredStatusMsgType
 	}
 }
 
-func (m *model) toggleView() {
+func (m *model) toggleView(reverse bool) {
+	next := map[view]view{allView: historyView, historyView: treeView, treeView: allView}
+	if reverse {
+		next = map[view]view{allView: treeView, historyView: allView, treeView: historyView}
+	}
+	m.currentView = next[m.currentView]
 	switch m.currentView {
 	case allView:
-		m.currentView = historyView
-		m.updateCurrentSelectedHistoryItem()
-	case historyView:
-		m.currentView = allView
 		m.updateCurrentSelectedAllItem()
+	case historyView:
+		m.updateCurrentSelectedHistoryItem()
+	case treeView:
+		m.updateCurrentSelectedTreeItem()
 	}
 }
 
 func (m *model) updateCurrentSelectedAllItem() {
 	if m.allList.SelectedItem() != nil {
 		selected := m.allList.SelectedItem().(*testCaseItem)
-		m.tmpTarget = tip.NewTarget(selected.path, selected.name, selected.isUnresolved)
+		m.tmpTarget = selected.target()
 		m.allBeforeSelected = m.allList.GlobalIndex()
 	}
 }
@@ -171,11 +221,175 @@ func (m *model) updateCurrentSelectedAllItem() {
 func (m *model) updateCurrentSelectedHistoryItem() {
 	if m.historyList.SelectedItem() != nil {
 		selected := m.historyList.SelectedItem().(*historyItem)
-		m.tmpTarget = tip.NewTarget(selected.path, selected.name, selected.isUnresolved)
+		if selected.isSet() {
+			// sets are run as they are, so there is no single target to adjust
+			m.tmpTarget = nil
+		} else {
+			target := *selected.targets[0]
+			m.tmpTarget = &target
+		}
 		m.historyBeforeSelected = m.historyList.GlobalIndex()
 	}
 }
 
+func (m *model) updateCurrentSelectedTreeItem() {
+	if m.treeList.SelectedItem() != nil {
+		selected := m.treeList.SelectedItem().(*treeNode)
+		if targets := selected.targets(); len(targets) == 1 {
+			m.tmpTarget = targets[0]
+		} else {
+			// a file has no single target to adjust
+			m.tmpTarget = nil
+		}
+		m.treeBeforeSelected = selected
+	}
+}
+
+// expandTreeNode expands the selected node, or moves to its first child if it is already expanded.
+func (m *model) expandTreeNode() {
+	selected, ok := m.treeList.SelectedItem().(*treeNode)
+	if !ok || !selected.hasChildren() {
+		return
+	}
+	if selected.expanded {
+		m.selectTreeNode(selected.children[0])
+		return
+	}
+	selected.expanded = true
+	m.refreshTreeItems(selected)
+}
+
+// collapseTreeNode collapses the selected node, or moves to its parent if it is not expanded.
+func (m *model) collapseTreeNode() {
+	selected, ok := m.treeList.SelectedItem().(*treeNode)
+	if !ok {
+		return
+	}
+	if selected.expanded {
+		selected.expanded = false
+		m.refreshTreeItems(selected)
+		return
+	}
+	if selected.parent != nil {
+		m.selectTreeNode(selected.parent)
+	}
+}
+
+// setTreeItems sets the nodes shown in the tree list, which its filter finds the ancestors of the matched nodes among.
+func (m *model) setTreeItems(items []list.Item) tea.Cmd {
+	m.treeList.Filter = treeFilter(matchFilter(m.matchFilterType), items)
+	return m.treeList.SetItems(items)
+}
+
+func (m *model) refreshTreeItems(selected *treeNode) {
+	m.setTreeItems(visibleTreeNodes(m.treeRoots))
+	m.selectTreeNode(selected)
+}
+
+func (m *model) selectTreeNode(node *treeNode) {
+	if i := slices.Index(m.treeList.Items(), list.Item(node)); i >= 0 {
+		m.treeList.Select(i)
+	}
+	m.updateCurrentSelectedTreeItem()
+}
+
+func (m *model) toggleMark() {
+	if m.currentView != allView || m.allList.SelectedItem() == nil {
+		return
+	}
+	selected := m.allList.SelectedItem().(*testCaseItem)
+	selected.marked = !selected.marked
+}
+
+func (m model) markedTargets() []*tip.Target {
+	targets := make([]*tip.Target, 0)
+	for _, item := range m.allList.Items() {
+		if i := item.(*testCaseItem); i.marked {
+			targets = append(targets, i.target())
+		}
+	}
+	return targets
+}
+
+// selectedTargets returns the targets to run:
+// the marked tests in the all view, the set of the selected history, the tests in the selected file of the tree,
+// or the selected target.
+func (m model) selectedTargets() []*tip.Target {
+	switch m.currentView {
+	case allView:
+		if targets := m.markedTargets(); len(targets) > 0 {
+			return targets
+		}
+	case historyView:
+		if selected, ok := m.historyList.SelectedItem().(*historyItem); ok && selected.isSet() {
+			return selected.targets
+		}
+	case treeView:
+		if selected, ok := m.treeList.SelectedItem().(*treeNode); ok && selected.nodeType == fileTreeNode {
+			return selected.targets()
+		}
+	}
+	return targetsOf(m.tmpTarget)
+}
+
+// run runs the targets in the UI in loop mode, or quits and returns them otherwise.
+func (m *model) run(targets []*tip.Target) tea.Cmd {
+	if m.loop != nil {
+		return m.startRun(targets)
+	}
+	m.retTargets = targets
+	return tea.Quit
+}
+
+func targetsOf(target *tip.Target) []*tip.Target {
+	if target == nil {
+		return nil
+	}
+	return []*tip.Target{target}
+}
+
+func (m *model) setStatusErr(err error) {
+	m.statusMsgType = errorStatusMsgType
+	m.statusErr = err
+}
+
+// selectedPosition returns the source position of the selected item.
+// Only tests in the all view and the tree view have positions, since histories do not hold them.
+func (m *model) selectedPosition() tip.Position {
+	switch m.currentView {
+	case allView:
+		if selected, ok := m.allList.SelectedItem().(*testCaseItem); ok {
+			return selected.pos
+		}
+	case treeView:
+		if selected, ok := m.treeList.SelectedItem().(*treeNode); ok {
+			return selected.pos
+		}
+	}
+	return tip.Position{}
+}
+
+func (m *model) togglePreview() {
+	m.showPreview = !m.showPreview
+	m.setSize(m.w, m.h)
+}
+
+// openEditor suspends the UI and opens the selected test in the editor.
+func (m *model) openEditor() tea.Cmd {
+	pos := m.selectedPosition()
+	if !pos.IsValid() {
+		return nil
+	}
+	cmd, err := command.Editor(pos, m.conf)
+	if err != nil {
+		m.setStatusErr(err)
+		return nil
+	}
+	return tea.ExecProcess(cmd, func(err error) tea.Msg {
+		return editorFinishedMsg{err: err}
+	})
+}
+
 func (m *model) openHelp() {
 	m.showHelp = true
 	m.helpOffset = 0
@@ -201,7 +415,7 @@ func (m *model) scrollHelpDown() {
 var _ tea.Model = (*model)(nil)
 
 func (m model) Init() tea.Cmd {
-	return nil
+	return m.discovery.start()
 }
 
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -210,7 +424,37 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
 		m.setSize(msg.Width, msg.Height)
+	case editorFinishedMsg:
+		m.preview.clearCache()
+		if msg.err != nil {
+			m.setStatusErr(fmt.Errorf("failed to open editor: %w", msg.err))
+		}
+		return m, nil
+	case testRunOutputMsg:
+		m.result.appendOutput(msg.output)
+		return m, m.result.waitForMsg()
+	case testRunFinishedMsg:
+		cmd := m.finishRun(msg)
+		m.preview.clearCache()
+		m.records = m.loop.Records()
+		updateTestCaseItemRecords(m.allList.Items(), m.records)
+		updateTreeNodeRecords(allTreeNodes(m.treeRoots), m.records)
+		m.historyList.SetItems(toHistoryItems(m.loop.Histories(), m.records, m.conf.History.DateFormat))
+		m.historyBeforeSelected = -1
+		return m, cmd
+	case watchStartedMsg:
+		return m.handleWatchStarted(msg)
+	case watchChangedMsg:
+		return m.handleWatchChanged(msg)
+	case discoveredMsg:
+		return m.handleDiscovered(msg)
+	case spinner.TickMsg:
+		return m, m.discovery.updateSpinner(msg)
 	case tea.KeyMsg:
+		if m.showResult {
+			return m.updateResult(msg)
+		}
+
 		if msg.String() == "ctrl+c" {
 			// exit
 			return m, tea.Quit
@@ -219,7 +463,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		// clear status message
 		m.statusMsgType = noneStatusMsgType
 
-		if m.allList.FilterState() == list.Filtering || m.historyList.FilterState() == list.Filtering {
+		if m.isFiltering() {
 			break
 		}
 
@@ -235,23 +479,81 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, nil
 		}
 
+		if m.showErrors {
+			switch msg.String() {
+			case "up", "k":
+				m.scrollErrorsUp()
+			case "down", "j":
+				m.scrollErrorsDown()
+			case "!", "esc", "backspace", "ctrl+h":
+				m.closeErrors()
+			}
+			return m, nil
+		}
+
 		switch msg.String() {
 		case "enter":
-			m.retTarget = m.tmpTarget
-			return m, tea.Quit
+			// tests can be run while the others are still being discovered
+			if targets := m.selectedTargets(); len(targets) > 0 {
+				return m, m.run(targets)
+			}
+			// nothing is selected, such as before any test is discovered
+			return m, nil
+		case "ctrl+f":
+			if m.tmpTarget != nil {
+				if fuzzTarget := m.tmpTarget.FuzzTarget(); fuzzTarget != nil {
+					return m, m.run(targetsOf(fuzzTarget))
+				}
+			}
+		case "ctrl+r":
+			targets := m.records.FailedTargets()
+			if len(targets) == 0 {
+				m.statusMsgType = noFailedTestsStatusMsgType
+				return m, nil
+			}
+			return m, m.run(targets)
+		case "ctrl+e":
+			return m, m.openEditor()
+		case "ctrl+p":
+			m.togglePreview()
+			return m, nil
+		case " ":
+			m.toggleMark()
+			return m, nil
 		case "backspace", "ctrl+h":
 			if m.tmpTarget != nil {
 				m.tmpTarget.DropLastSegment()
 			}
 		case "tab", "shift+tab":
-			m.toggleView()
+			m.toggleView(msg.String() == "shift+tab")
+		case "right", "l":
+			if m.currentView == treeView && m.treeList.FilterState() == list.Unfiltered {
+				m.expandTreeNode()
+				return m, nil
+			}
+		case "left", "h":
+			if m.currentView == treeView && m.treeList.FilterState() == list.Unfiltered {
+				m.collapseTreeNode()
+				return m, nil
+			}
+		case "/":
+			if m.currentView == treeView && m.treeList.FilterState() == list.Unfiltered {
+				// filter all nodes, including the ones in collapsed nodes
+				m.setTreeItems(allTreeNodes(m.treeRoots))
+				m.treeShowsAll = true
+			}
 		case "ctrl+x":
-			if m.allList.FilterState() == list.Unfiltered || m.historyList.FilterState() == list.Unfiltered {
+			if m.allList.FilterState() == list.Unfiltered || m.historyList.FilterState() == list.Unfiltered || m.treeList.FilterState() == list.Unfiltered {
 				m.toggleMatchFilter()
 			}
 		case "?":
 			m.openHelp()
 			return m, nil
+		case "!":
+			if len(m.discovery.errorLines()) > 0 {
+				m.openErrors()
+			}
+			return m, nil
 		}
 	}
 
@@ -272,6 +574,28 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if m.historyBeforeSelected != m.historyList.GlobalIndex() {
 			m.updateCurrentSelectedHistoryItem()
 		}
+	case treeView:
+		newList, cmd := m.treeList.Update(msg)
+		m.treeList = newList
+		cmds = append(cmds, cmd)
+
+		if m.treeShowsAll && m.treeList.FilterState() == list.Unfiltered {
+			// back to the expanded nodes only once the filter is cleared, keeping the selected node visible
+			m.treeShowsAll = false
+			selected := m.treeBeforeSelected
+			if selected != nil {
+				for p := selected.parent; p != nil; p = p.parent {
+					p.expanded = true
+				}
+			}
+			m.setTreeItems(visibleTreeNodes(m.treeRoots))
+			if selected != nil {
+				m.selectTreeNode(selected)
+			}
+		}
+		if m.treeList.SelectedItem() != m.treeBeforeSelected {
+			m.updateCurrentSelectedTreeItem()
+		}
 	}
 
 	return m, tea.Batch(cmds...)
@@ -284,6 +608,12 @@ func (m model) View() string {
 	if m.showHelp {
 		return m.helpView()
 	}
+	if m.showResult {
+		return m.resultView()
+	}
+	if m.showErrors {
+		return m.errorsView()
+	}
 
 	var currentList list.Model
 	switch m.currentView {
@@ -291,10 +621,22 @@ func (m model) View() string {
 		currentList = m.allList
 	case historyView:
 		currentList = m.historyList
+	case treeView:
+		currentList = m.treeList
 	}
 
 	var headerContent string
-	if m.tmpTarget != nil {
+	if targets := m.selectedTargets(); len(targets) > 1 {
+		selectedLabel := fmt.Sprintf("Selected (%d): ", len(targets))
+		selectedNameWidth := m.w - headerStyle.GetHorizontalFrameSize() - lipgloss.Width(selectedLabel)
+		selectedName := ansi.Truncate(targetNames(targets), selectedNameWidth, ellipsis)
+		name := selectedLabelStyle.Render(selectedLabel) + selectedNameStyle.Render(selectedName)
+
+		packLabel := " Package: "
+		packWidth := m.w - headerStyle.GetHorizontalFrameSize() - lipgloss.Width(packLabel)
+		pack := selectedLabelStyle.Render(packLabel) + selectedPathStyle.Render(ansi.Truncate(targetPackages(targets), packWidth, ellipsis))
+		headerContent = name + "\n" + pack
+	} else if m.tmpTarget != nil {
 		selectedLabel := "Selected: "
 		selectedNameWidth := m.w - headerStyle.GetHorizontalFrameSize() - lipgloss.Width(selectedLabel)
 		if m.tmpTarget.IsPrefix {
@@ -314,6 +656,12 @@ func (m model) View() string {
 
 	header := headerStyle.Width(m.w).Render(headerContent)
 
+	content := currentList.View()
+	if m.showPreview {
+		listView := lipgloss.NewStyle().Width(currentList.Width()).Render(content)
+		content = lipgloss.JoinHorizontal(lipgloss.Top, listView, m.preview.view(m.selectedPosition()))
+	}
+
 	var footerStatus string
 	switch m.statusMsgType {
 	case noneStatusMsgType:
@@ -330,6 +678,12 @@ func (m model) View() string {
 	case exactMatchFilteredStatusMsgType:
 		footerStatus = footerMsgStyle.
 			Render("Filter mode: Exact match")
+	case noFailedTestsStatusMsgType:
+		footerStatus = footerMsgStyle.
+			Render("No failed tests in the last run")
+	case errorStatusMsgType:
+		footerStatus = footerErrorMsgStyle.
+			Render(m.statusErr.Error())
 	}
 
 	var footerSelectedIndex string
@@ -337,6 +691,16 @@ func (m model) View() string {
 		footerSelectedIndex = footerSelectedIndexStyle.
 			Render(fmt.Sprintf("%d / %d", currentList.Index()+1, len(currentList.VisibleItems())))
 	}
+	if marked := len(m.markedTargets()); marked > 0 && m.currentView == allView {
+		footerSelectedIndex = footerMsgStyle.Render(fmt.Sprintf("%d marked", marked)) +
+			footerDividerStyle.Render(" | ") + footerSelectedIndex
+	}
+	if discovery := m.discovery.statusView(); discovery != "" {
+		if footerSelectedIndex != "" {
+			discovery += footerDividerStyle.Render(" | ")
+		}
+		footerSelectedIndex = discovery + footerSelectedIndex
+	}
 
 	var footerView string
 	switch m.currentView {
@@ -344,6 +708,8 @@ func (m model) View() string {
 		footerView = footerDividerStyle.Render(" | ") + footerMsgStyle.Render("All Tests")
 	case historyView:
 		footerView = footerDividerStyle.Render(" | ") + footerMsgStyle.Render("History  ")
+	case treeView:
+		footerView = footerDividerStyle.Render(" | ") + footerMsgStyle.Render("Tree     ")
 	}
 
 	footerSpaceWidth := max(m.w-lipgloss.Width(footerStatus)-lipgloss.Width(footerSelectedIndex)-lipgloss.Width(footerView)-2 /* padding */, 0)
@@ -351,7 +717,7 @@ func (m model) View() string {
 
 	footer := footerStyle.Width(m.w).Render(footerStatus + footerSpace + footerSelectedIndex + footerView)
 
-	return lipgloss.JoinVertical(lipgloss.Left, header, currentList.View(), footer)
+	return lipgloss.JoinVertical(lipgloss.Left, header, content, footer)
 }
 
 func (m model) helpView() string {
@@ -412,27 +778,74 @@ func helpItems() []helpItem {
 		{keys: []string{"Right", "l"}, desc: "Select next page"},
 		{keys: []string{"Left", "h"}, desc: "Select previous page"},
 		{keys: []string{"Enter"}, desc: "Run the selected test / Confirm filter (in filtering mode)"},
+		{keys: []string{"Space"}, desc: "Mark the selected test to run multiple tests together"},
+		{keys: []string{"Ctrl-f"}, desc: "Start fuzzing the selected fuzz test"},
+		{keys: []string{"Ctrl-e"}, desc: "Open the selected test in the editor"},
+		{keys: []string{"Ctrl-p"}, desc: "Toggle the source preview of the selected test"},
+		{keys: []string{"Ctrl-r"}, desc: "Rerun the tests that failed in the last run"},
 		{keys: []string{"Backspace"}, desc: "Select parent test group"},
 		{keys: []string{"/"}, desc: "Enter filtering mode"},
 		{keys: []string{"Esc"}, desc: "Clear filtering mode"},
 		{keys: []string{"Ctrl-x"}, desc: "Toggle filtering type"},
+		{keys: []string{"Right", "l"}, desc: "Expand the selected node (in the tree view)"},
+		{keys: []string{"Left", "h"}, desc: "Collapse the selected node or select its parent (in the tree view)"},
 		{keys: []string{"Tab"}, desc: "Switch view"},
+		{keys: []string{"!"}, desc: "Show the files which could not be processed in test discovery"},
 		{keys: []string{"?"}, desc: "Show help"},
 	}
 }
 
+// Start shows the UI while the tests are discovered in the background, and returns the targets to run.
+// The discovered tests are added to the lists as they are received from discovery until it is closed.
 func Start(
-	tests map[string][]*tip.TestFunction,
+	discovery <-chan *tip.DiscoveryResult,
 	histories *tip.Histories,
+	records *tip.TestRecords,
 	conf *tip.Config,
 	defaultViewStr string,
 	defaultFilterTypeStr string,
-) (*tip.Target, error) {
-	allTestItems := toTestCaseItems(tests)
-	historyItems := toHistoryItems(histories, conf.History.DateFormat)
+) ([]*tip.Target, error) {
+	ret, err := start(discovery, histories, records, conf, nil, defaultViewStr, defaultFilterTypeStr)
+	if err != nil {
+		return nil, err
+	}
+	return ret.retTargets, nil
+}
+
+// StartLoop starts the UI in loop mode, which runs tests with loop.Run until the user quits.
+func StartLoop(
+	discovery <-chan *tip.DiscoveryResult,
+	histories *tip.Histories,
+	records *tip.TestRecords,
+	conf *tip.Config,
+	loop *Loop,
+	defaultViewStr string,
+	defaultFilterTypeStr string,
+) error {
+	ret, err := start(discovery, histories, records, conf, loop, defaultViewStr, defaultFilterTypeStr)
+	if err != nil {
+		return err
+	}
+	// stop the test if the user quits while it is running
+	ret.result.cancelRun()
+	ret.result.stopWatch()
+	return nil
+}
+
+func start(
+	discovery <-chan *tip.DiscoveryResult,
+	histories *tip.Histories,
+	records *tip.TestRecords,
+	conf *tip.Config,
+	loop *Loop,
+	defaultViewStr string,
+	defaultFilterTypeStr string,
+) (model, error) {
+	historyItems := toHistoryItems(histories, records, conf.History.DateFormat)
 	defaultView := viewFromStr(defaultViewStr)
 	defaultFilterType := matchFilterTypeFromStr(defaultFilterTypeStr)
-	m := newModel(allTestItems, historyItems, defaultView, defaultFilterType)
+	m := newModel([]list.Item{}, historyItems, nil, records, conf, loop, defaultView, defaultFilterType)
+	m.discovery = newDiscoveryState(discovery)
 	p := tea.NewProgram(
 		m,
 		tea.WithAltScreen(),
@@ -440,7 +853,7 @@ func Start(
 	)
 	ret, err := p.Run()
 	if err != nil {
-		return nil, err
+		return model{}, err
 	}
-	return ret.(model).retTarget, nil
+	return ret.(model), nil
 }
@@ -0,0 +1,175 @@
+package ui
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/lusingander/gotip/internal/tip"
+)
+
+func TestMarkedTestsAreRunTogether(t *testing.T) {
+	items := []list.Item{
+		&testCaseItem{path: "./foo/foo_test.go", name: "TestA"},
+		&testCaseItem{path: "./foo/foo_test.go", name: "TestB"},
+		&testCaseItem{path: "./bar/bar_test.go", name: "TestC"},
+	}
+	conf := &tip.Config{History: tip.HistoryConfig{DateFormat: "2006-01-02"}}
+	var m tea.Model = newModel(items, []list.Item{}, nil, nil, conf, nil, allView, fuzzyMatchFilterType)
+	m, _ = m.Update(tea.WindowSizeMsg{Width: 80, Height: 24})
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeySpace}) // mark TestA
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeySpace}) // mark TestC
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyUp})
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeySpace}) // mark TestB
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeySpace}) // unmark TestB
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	got := m.(model).retTargets
+	if len(got) != 2 || got[0].TestNamePattern != "TestA" || got[1].TestNamePattern != "TestC" {
+		t.Errorf("targets = %+v, want TestA and TestC", got)
+	}
+}
+
+func TestHistorySetIsRunTogether(t *testing.T) {
+	histories := &tip.Histories{Histories: []*tip.History{}}
+	histories.AddSet([]*tip.Target{
+		tip.NewTarget("./foo/foo_test.go", "TestA", tip.TestKindTest, false),
+		tip.NewTarget("./bar/bar_test.go", "TestC", tip.TestKindTest, false),
+	}, 10)
+	conf := &tip.Config{History: tip.HistoryConfig{DateFormat: "2006-01-02"}}
+	historyItems := toHistoryItems(histories, nil, conf.History.DateFormat)
+	var m tea.Model = newModel([]list.Item{}, historyItems, nil, nil, conf, nil, historyView, fuzzyMatchFilterType)
+	m, _ = m.Update(tea.WindowSizeMsg{Width: 80, Height: 24})
+
+	item := historyItems[0].(*historyItem)
+	if item.nameForView != "TestA, TestC" || item.location() != "./foo, ./bar" {
+		t.Errorf("history item = %q in %q, want \"TestA, TestC\" in \"./foo, ./bar\"", item.nameForView, item.location())
+	}
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	got := m.(model).retTargets
+	if len(got) != 2 || got[0].PackageName != "./foo" || got[1].PackageName != "./bar" {
+		t.Errorf("targets = %+v, want TestA and TestC", got)
+	}
+}
+
+func TestSubTestsAreShownWithDisplayNames(t *testing.T) {
+	tests := map[string][]*tip.TestFunction{
+		"./foo/foo_test.go": {
+			{Name: "TestA", Kind: tip.TestKindTest, Subs: []*tip.SubTest{
+				{Name: "handles_empty_input", DisplayName: "handles empty input", Resolved: true, Subs: []*tip.SubTest{
+					{Name: "x", Resolved: true},
+				}},
+			}},
+		},
+	}
+
+	item := toTestCaseItems(tests, nil)[0].(*testCaseItem)
+	if got, want := item.FilterValue(), "TestA/handles empty input/x"; got != want {
+		t.Errorf("item title = %q, want %q", got, want)
+	}
+	if got, want := item.target().TestNamePattern, "TestA/handles_empty_input/x"; got != want {
+		t.Errorf("item target = %q, want %q", got, want)
+	}
+
+	node := allTreeNodes(toTreeNodes(tests, nil))[3].(*treeNode)
+	if node.label != "handles empty input" || node.targets()[0].TestNamePattern != "TestA/handles_empty_input/" {
+		t.Errorf("tree node = %q with targets %+v, want the display name and the rewritten name", node.label, node.targets()[0])
+	}
+}
+
+func TestDiscoveredTestsAreAddedKeepingMarks(t *testing.T) {
+	conf := &tip.Config{History: tip.HistoryConfig{DateFormat: "2006-01-02"}}
+	m := newModel([]list.Item{}, []list.Item{}, nil, nil, conf, nil, allView, fuzzyMatchFilterType)
+	m.discovery = newDiscoveryState(make(chan *tip.DiscoveryResult))
+	var tm tea.Model = m
+	tm, _ = tm.Update(tea.WindowSizeMsg{Width: 80, Height: 24})
+
+	// nothing is run before any test is discovered
+	tm, cmd := tm.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if cmd != nil || tm.(model).retTargets != nil {
+		t.Fatal("enter with no tests should do nothing")
+	}
+
+	tm, _ = tm.Update(discoveredMsg{result: &tip.DiscoveryResult{Tests: map[string][]*tip.TestFunction{
+		"./foo/foo_test.go": {{Name: "TestFoo", Kind: tip.TestKindTest}},
+	}}})
+	tm, _ = tm.Update(tea.KeyMsg{Type: tea.KeySpace}) // mark TestFoo
+
+	tm, _ = tm.Update(discoveredMsg{result: &tip.DiscoveryResult{
+		Tests: map[string][]*tip.TestFunction{
+			"./bar/bar_test.go": {{Name: "TestBar", Kind: tip.TestKindTest}},
+		},
+		Errors: []error{errors.New("error processing file ./baz/baz_test.go")},
+	}})
+	// errors not related to a package are not counted as packages
+	tm, _ = tm.Update(discoveredMsg{result: &tip.DiscoveryResult{Errors: []error{errors.New("failed to save the cache")}}})
+	got := tm.(model)
+	if len(got.allList.Items()) != 2 || got.allList.Items()[0].(*testCaseItem).name != "TestBar" {
+		t.Errorf("items = %v, want TestBar and TestFoo in order of paths", got.allList.Items())
+	}
+	if selected := got.allList.SelectedItem().(*testCaseItem); selected.name != "TestFoo" {
+		t.Errorf("selected = %s, want TestFoo to stay selected", selected.name)
+	}
+	if marked := got.markedTargets(); len(marked) != 1 || marked[0].TestNamePattern != "TestFoo" {
+		t.Errorf("marked = %+v, want TestFoo", marked)
+	}
+	if len(got.treeRoots) != 2 || got.treeRoots[0].label != "./bar" {
+		t.Errorf("tree roots = %v, want ./bar and ./foo", got.treeRoots)
+	}
+	if !got.discovery.running || got.discovery.packages != 2 {
+		t.Errorf("discovery = running %t with %d packages, want running with 2", got.discovery.running, got.discovery.packages)
+	}
+
+	// the errors are shown once the discovery is done, and can be dismissed
+	tm, _ = tm.Update(discoveredMsg{done: true})
+	if got := tm.(model); got.discovery.running || !got.showErrors {
+		t.Errorf("discovery running = %t, errors shown = %t, want done with errors shown", got.discovery.running, got.showErrors)
+	}
+	tm, _ = tm.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if tm.(model).showErrors {
+		t.Error("errors are still shown after esc")
+	}
+}
+
+func TestDiscoveredTestsInFileWithErrors(t *testing.T) {
+	conf := &tip.Config{History: tip.HistoryConfig{DateFormat: "2006-01-02"}}
+	m := newModel([]list.Item{}, []list.Item{}, nil, nil, conf, nil, allView, fuzzyMatchFilterType)
+	m.discovery = newDiscoveryState(make(chan *tip.DiscoveryResult))
+	var tm tea.Model = m
+	tm, _ = tm.Update(tea.WindowSizeMsg{Width: 80, Height: 24})
+
+	tm, _ = tm.Update(discoveredMsg{result: &tip.DiscoveryResult{
+		Tests: map[string][]*tip.TestFunction{
+			"./foo/foo_test.go": {{Name: "TestFoo", Kind: tip.TestKindTest, FileHasErrors: true}},
+		},
+		Diagnostics: map[string][]tip.Diagnostic{
+			"./foo/foo_test.go": {{Pos: tip.Position{File: "./foo/foo_test.go", Line: 9, Column: 2}, Message: "expected '}', found 'EOF'"}},
+		},
+	}})
+	got := tm.(model)
+	if item := got.allList.Items()[0].(*testCaseItem); item.label() != fileHasErrorsLabel {
+		t.Errorf("label = %q, want %q", item.label(), fileHasErrorsLabel)
+	}
+	if file := got.treeRoots[0].children[0]; !file.fileHasErrors {
+		t.Error("the file node is not marked as having errors")
+	}
+
+	// syntax errors are not shown until requested
+	tm, _ = tm.Update(discoveredMsg{done: true})
+	if tm.(model).showErrors {
+		t.Error("errors are shown for syntax errors only")
+	}
+	if status := tm.(model).discovery.statusView(); !strings.Contains(status, "1 discovery error") {
+		t.Errorf("status = %q, want the number of errors", status)
+	}
+	tm, _ = tm.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'!'}})
+	if got := tm.(model); !got.showErrors || !strings.Contains(got.errorsView(), "./foo/foo_test.go:9:2: expected '}', found 'EOF'") {
+		t.Error("syntax errors are not shown with !")
+	}
+}
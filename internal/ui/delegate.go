@@ -3,11 +3,13 @@ package ui
 import (
 	"fmt"
 	"io"
+	"strings"
 
 	"github.com/charmbracelet/bubbles/list"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/charmbracelet/x/ansi"
+	"github.com/lusingander/gotip/internal/tip"
 )
 
 var (
@@ -17,6 +19,9 @@ var (
 	listMatchedColor     = lipgloss.Color("#CE3262")
 	listDimmedTitleColor = lipgloss.Color("#777777")
 	listDimmedDescColor  = lipgloss.Color("#4D4D4D")
+	listPassedColor      = lipgloss.Color("#00A29C")
+	listFailedColor      = lipgloss.Color("#CE3262")
+	listSkippedColor     = lipgloss.Color("#777777")
 )
 
 var (
@@ -42,10 +47,20 @@ var (
 
 	listDimmedDescStyle = listDimmedTitleStyle.
 				Foreground(listDimmedDescColor)
+
+	listPassedBadgeStyle = lipgloss.NewStyle().
+				Foreground(listPassedColor)
+
+	listFailedBadgeStyle = lipgloss.NewStyle().
+				Foreground(listFailedColor)
+
+	listSkippedBadgeStyle = lipgloss.NewStyle().
+				Foreground(listSkippedColor)
 )
 
 const (
-	ellipsis = "..."
+	ellipsis     = "..."
+	markedPrefix = "● "
 )
 
 type testCaseItemDelegate struct{}
@@ -64,15 +79,22 @@ func (d testCaseItemDelegate) Update(msg tea.Msg, m *list.Model) tea.Cmd {
 
 func (d testCaseItemDelegate) Render(w io.Writer, m list.Model, index int, item list.Item) {
 	i := item.(*testCaseItem)
-	title := i.name
-	desc := i.path
+	title := i.title()
+	desc := i.label() + i.location()
+	badge := statusBadge(i.record)
+
+	var mark string
+	if i.marked {
+		mark = markedPrefix
+		title = mark + title
+	}
 
 	if m.Width() <= 0 {
 		return
 	}
 
 	textwidth := m.Width() - listNormalTitleStyle.GetPaddingLeft() - listNormalTitleStyle.GetPaddingRight()
-	title = ansi.Truncate(title, textwidth, ellipsis)
+	title = ansi.Truncate(title, max(textwidth-lipgloss.Width(badge), 0), ellipsis)
 	desc = ansi.Truncate(desc, textwidth, ellipsis)
 
 	var (
@@ -83,7 +105,7 @@ func (d testCaseItemDelegate) Render(w io.Writer, m list.Model, index int, item
 
 	var matchedRunes []int
 	if isFiltered && index < len(m.VisibleItems()) {
-		matchedRunes = m.MatchesForItem(index)
+		matchedRunes = shiftRunes(m.MatchesForItem(index), len([]rune(mark)))
 	}
 
 	if emptyFilter {
@@ -119,7 +141,7 @@ func (d testCaseItemDelegate) Render(w io.Writer, m list.Model, index int, item
 		}
 	}
 
-	fmt.Fprintf(w, "%s\n%s", title, desc)
+	fmt.Fprintf(w, "%s%s\n%s", title, badge, desc)
 }
 
 type historyItemDelegate struct{}
@@ -139,15 +161,16 @@ func (d historyItemDelegate) Update(msg tea.Msg, m *list.Model) tea.Cmd {
 func (d historyItemDelegate) Render(w io.Writer, m list.Model, index int, item list.Item) {
 	i := item.(*historyItem)
 	title := i.nameForView
-	desc := i.path
+	desc := kindLabel(i.kind) + i.location()
 	runAt := i.runAt
+	badge := statusBadge(i.record)
 
 	if m.Width() <= 0 {
 		return
 	}
 
 	textwidth := m.Width() - listNormalTitleStyle.GetPaddingLeft() - listNormalTitleStyle.GetPaddingRight()
-	title = ansi.Truncate(title, textwidth, ellipsis)
+	title = ansi.Truncate(title, max(textwidth-lipgloss.Width(badge), 0), ellipsis)
 	desc = ansi.Truncate(desc, textwidth, ellipsis)
 	runAt = ansi.Truncate(runAt, textwidth, ellipsis)
 
@@ -199,5 +222,131 @@ func (d historyItemDelegate) Render(w io.Writer, m list.Model, index int, item l
 		}
 	}
 
-	fmt.Fprintf(w, "%s\n%s\n%s", title, desc, runAt)
+	fmt.Fprintf(w, "%s%s\n%s\n%s", title, badge, desc, runAt)
+}
+
+// statusBadge returns the status glyph and duration of the latest run of the test,
+// or an empty string if the test has never been run.
+func statusBadge(record *tip.TestRecord) string {
+	if record == nil {
+		return ""
+	}
+	var glyph string
+	var style lipgloss.Style
+	switch record.Status {
+	case tip.TestStatusPass:
+		glyph, style = "✓", listPassedBadgeStyle
+	case tip.TestStatusFail:
+		glyph, style = "✗", listFailedBadgeStyle
+	case tip.TestStatusSkip:
+		glyph, style = "-", listSkippedBadgeStyle
+	default:
+		return ""
+	}
+	return " " + style.Render(fmt.Sprintf("%s %.2fs", glyph, record.Elapsed.Seconds()))
+}
+
+// shiftRunes shifts the indices of matched runes by n, for a title with a prefix that is not part of the filter value.
+func shiftRunes(runes []int, n int) []int {
+	if n == 0 {
+		return runes
+	}
+	shifted := make([]int, len(runes))
+	for i, r := range runes {
+		shifted[i] = r + n
+	}
+	return shifted
+}
+
+type treeItemDelegate struct{}
+
+func (d treeItemDelegate) Height() int {
+	return 1
+}
+
+func (d treeItemDelegate) Spacing() int {
+	return 0
+}
+
+func (d treeItemDelegate) Update(msg tea.Msg, m *list.Model) tea.Cmd {
+	return nil
+}
+
+func (d treeItemDelegate) Render(w io.Writer, m list.Model, index int, item list.Item) {
+	n := item.(*treeNode)
+
+	var icon string
+	switch {
+	case !n.hasChildren():
+		icon = "  "
+	case n.expanded || m.FilterState() != list.Unfiltered:
+		// all ancestors of matched nodes are shown while filtering
+		icon = "▾ "
+	default:
+		icon = "▸ "
+	}
+	prefix := strings.Repeat("  ", n.depth) + icon
+	if n.nodeType == testTreeNode && n.depth == 2 {
+		prefix += kindLabel(n.kind)
+	}
+	if n.nodeType == fileTreeNode && n.fileHasErrors {
+		prefix += fileHasErrorsLabel
+	}
+	title := prefix + n.label
+	badge := statusBadge(n.record)
+
+	if m.Width() <= 0 {
+		return
+	}
+
+	textwidth := m.Width() - listNormalTitleStyle.GetPaddingLeft() - listNormalTitleStyle.GetPaddingRight()
+	title = ansi.Truncate(title, max(textwidth-lipgloss.Width(badge), 0), ellipsis)
+
+	var (
+		isSelected  = index == m.Index()
+		emptyFilter = m.FilterState() == list.Filtering && m.FilterValue() == ""
+		isFiltered  = m.FilterState() == list.Filtering || m.FilterState() == list.FilterApplied
+	)
+
+	var matchedRunes []int
+	if isFiltered && index < len(m.VisibleItems()) {
+		// the key ends with the label, and matches in the preceding part are not shown
+		offset := len([]rune(n.key)) - len([]rune(n.label))
+		for _, r := range shiftRunes(m.MatchesForItem(index), len([]rune(prefix))-offset) {
+			if r >= len([]rune(prefix)) {
+				matchedRunes = append(matchedRunes, r)
+			}
+		}
+	}
+
+	if emptyFilter {
+		title = listDimmedTitleStyle.Render(title)
+	} else {
+		if isSelected && m.FilterState() != list.Filtering {
+			if isFiltered {
+				unmatched := listSelectedTitleStyle.Inline(true)
+				matched := unmatched.Foreground(listMatchedColor)
+				title = lipgloss.StyleRunes(title, matchedRunes, matched, unmatched)
+			}
+			title = listSelectedTitleStyle.Render(title)
+		} else {
+			if m.FilterState() == list.Filtering {
+				if isFiltered {
+					unmatched := listDimmedTitleStyle.Inline(true)
+					matched := unmatched.Foreground(listMatchedColor)
+					title = lipgloss.StyleRunes(title, matchedRunes, matched, unmatched)
+				}
+				title = listDimmedTitleStyle.Render(title)
+			} else {
+				if isFiltered {
+					unmatched := listNormalTitleStyle.Inline(true)
+					matched := unmatched.Foreground(listMatchedColor)
+					title = lipgloss.StyleRunes(title, matchedRunes, matched, unmatched)
+				}
+				title = listNormalTitleStyle.Render(title)
+			}
+		}
+	}
+
+	fmt.Fprintf(w, "%s%s", title, badge)
 }
@@ -0,0 +1,214 @@
+package ui
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/lusingander/gotip/internal/tip"
+)
+
+var discoverySpinnerStyle = lipgloss.NewStyle().Foreground(selectedColor)
+
+// discoveredMsg is sent when the tests of a package are discovered, or when the discovery is done.
+type discoveredMsg struct {
+	result *tip.DiscoveryResult
+	done   bool
+}
+
+// discoveryState is the state of the test discovery running in the background while the UI is shown.
+type discoveryState struct {
+	results  <-chan *tip.DiscoveryResult
+	running  bool
+	packages int // number of the packages discovered so far
+	errors   []error
+	// syntax errors of the files, whose tests are listed as far as they are parsed
+	diagnostics []tip.Diagnostic
+	spinner     spinner.Model
+}
+
+func newDiscoveryState(results <-chan *tip.DiscoveryResult) discoveryState {
+	return discoveryState{
+		results: results,
+		running: results != nil,
+		spinner: spinner.New(spinner.WithSpinner(spinner.Dot), spinner.WithStyle(discoverySpinnerStyle)),
+	}
+}
+
+func (d *discoveryState) start() tea.Cmd {
+	if !d.running {
+		return nil
+	}
+	return tea.Batch(d.waitForResult(), d.spinner.Tick)
+}
+
+func (d *discoveryState) waitForResult() tea.Cmd {
+	results := d.results
+	return func() tea.Msg {
+		result, ok := <-results
+		return discoveredMsg{result: result, done: !ok}
+	}
+}
+
+func (d *discoveryState) updateSpinner(msg spinner.TickMsg) tea.Cmd {
+	if !d.running {
+		// stop ticking once done
+		return nil
+	}
+	var cmd tea.Cmd
+	d.spinner, cmd = d.spinner.Update(msg)
+	return cmd
+}
+
+func (d discoveryState) statusView() string {
+	switch n := len(d.errorLines()); {
+	case d.running:
+		return d.spinner.View() + " " + footerMsgStyle.Render(fmt.Sprintf("Discovering tests (%d packages)", d.packages))
+	case n == 1:
+		return footerErrorMsgStyle.Render("1 discovery error (!)")
+	case n > 1:
+		return footerErrorMsgStyle.Render(fmt.Sprintf("%d discovery errors (!)", n))
+	}
+	return ""
+}
+
+// errorLines returns the errors of the files which could not be processed, followed by the syntax errors of the files.
+func (d discoveryState) errorLines() []string {
+	lines := make([]string, 0, len(d.errors)+len(d.diagnostics))
+	for _, err := range d.errors {
+		lines = append(lines, err.Error())
+	}
+	for _, diagnostic := range d.diagnostics {
+		lines = append(lines, diagnostic.String())
+	}
+	return lines
+}
+
+func (m model) handleDiscovered(msg discoveredMsg) (tea.Model, tea.Cmd) {
+	if msg.done {
+		m.discovery.running = false
+		// syntax errors are usual while editing the files, so only the files which could not be processed are notified
+		if len(m.discovery.errors) > 0 && !m.isFiltering() && !m.showHelp && !m.showResult {
+			m.openErrors()
+		}
+		return m, nil
+	}
+	cmd := m.addDiscoveredTests(msg.result)
+	return m, tea.Batch(cmd, m.discovery.waitForResult())
+}
+
+// addDiscoveredTests adds the tests of a package to the lists, keeping the selection and the marks of the tests shown so far.
+func (m *model) addDiscoveredTests(result *tip.DiscoveryResult) tea.Cmd {
+	if result.Dir != "" || len(result.Tests) > 0 {
+		m.discovery.packages++
+	}
+	m.discovery.errors = append(m.discovery.errors, result.Errors...)
+	for _, path := range slices.Sorted(maps.Keys(result.Diagnostics)) {
+		m.discovery.diagnostics = append(m.discovery.diagnostics, result.Diagnostics[path]...)
+	}
+	if len(result.Tests) == 0 {
+		return nil
+	}
+	cmds := make([]tea.Cmd, 0)
+
+	selectedItem := m.allList.SelectedItem()
+	items := append(slices.Clone(m.allList.Items()), toTestCaseItems(result.Tests, m.records)...)
+	// same order as toTestCaseItems, since the tests of a file are discovered at once
+	slices.SortStableFunc(items, func(a, b list.Item) int {
+		return cmp.Compare(a.(*testCaseItem).path, b.(*testCaseItem).path)
+	})
+	cmds = append(cmds, m.allList.SetItems(items))
+	if selectedItem == nil {
+		if m.currentView == allView {
+			m.updateCurrentSelectedAllItem()
+		}
+	} else if m.allList.FilterState() == list.Unfiltered {
+		m.allList.Select(slices.Index(items, selectedItem))
+		m.allBeforeSelected = m.allList.GlobalIndex()
+	}
+
+	m.treeRoots = append(m.treeRoots, toTreeNodes(result.Tests, m.records)...)
+	slices.SortStableFunc(m.treeRoots, func(a, b *treeNode) int {
+		return cmp.Compare(a.label, b.label)
+	})
+	if m.treeShowsAll {
+		cmds = append(cmds, m.setTreeItems(allTreeNodes(m.treeRoots)))
+	} else {
+		cmds = append(cmds, m.setTreeItems(visibleTreeNodes(m.treeRoots)))
+	}
+	if m.treeBeforeSelected == nil {
+		if m.currentView == treeView {
+			m.updateCurrentSelectedTreeItem()
+		}
+	} else if m.treeList.FilterState() == list.Unfiltered {
+		m.selectTreeNode(m.treeBeforeSelected)
+	}
+	return tea.Batch(cmds...)
+}
+
+func (m model) isFiltering() bool {
+	return m.allList.FilterState() == list.Filtering || m.historyList.FilterState() == list.Filtering || m.treeList.FilterState() == list.Filtering
+}
+
+func (m *model) openErrors() {
+	m.showErrors = true
+	m.errorsOffset = 0
+}
+
+func (m *model) closeErrors() {
+	m.showErrors = false
+	m.errorsOffset = 0
+}
+
+func (m *model) scrollErrorsUp() {
+	if m.errorsOffset > 0 {
+		m.errorsOffset--
+	}
+}
+
+func (m *model) scrollErrorsDown() {
+	if m.errorsOffset < len(m.discovery.errorLines())-1 {
+		m.errorsOffset++
+	}
+}
+
+// errorsView shows the errors of the files which could not be processed in the discovery, and the syntax errors of the files.
+func (m model) errorsView() string {
+	headerTitle := helpHeaderStyle.Render("Discovery errors")
+	headerDesc := footerMsgStyle.Render("Tests in these files are not listed, or may be incomplete")
+	header := headerStyle.Width(m.w).Render(headerTitle + "\n" + headerDesc)
+
+	contentHeight := m.h - 5
+	lineWidth := m.w - helpContentStyle.GetHorizontalFrameSize()
+	lines := []string{}
+	for i, errLine := range m.discovery.errorLines() {
+		if i < m.errorsOffset {
+			continue
+		}
+		if len(lines) >= contentHeight {
+			break
+		}
+		// each error is shown in a line, as the width of the error messages is unknown
+		line := strings.ReplaceAll(errLine, "\n", " ")
+		lines = append(lines, footerErrorMsgStyle.Render(ansi.Truncate(line, lineWidth, ellipsis)))
+	}
+
+	padLines := strings.Repeat("\n", max(contentHeight-len(lines), 0))
+	content := helpContentStyle.Render(strings.Join(lines, "\n") + padLines)
+
+	footerView := footerDividerStyle.Render(" | ") + footerMsgStyle.Render("Errors   ")
+
+	footerSpaceWidth := max(m.w-lipgloss.Width(footerView)-2 /* padding */, 0)
+	footerSpace := strings.Repeat(" ", footerSpaceWidth)
+
+	footer := footerStyle.Width(m.w).Render(footerSpace + footerView)
+
+	return lipgloss.JoinVertical(lipgloss.Left, header, content, footer)
+}
@@ -24,6 +24,15 @@ func matchFilterTypeFromStr(s string) matchFilterType {
 	}
 }
 
+func matchFilter(t matchFilterType) list.FilterFunc {
+	switch t {
+	case exactMatchFilterType:
+		return exactMatchFilter
+	default:
+		return fuzzyMatchFilter
+	}
+}
+
 func fuzzyMatchFilter(term string, targets []string) []list.Rank {
 	ranks := list.DefaultFilter(term, targets)
 	return convertRanks(ranks, targets)
@@ -3,96 +3,253 @@ package ui
 import (
 	"cmp"
 	"slices"
+	"strings"
 
 	"github.com/charmbracelet/bubbles/list"
 	"github.com/lusingander/gotip/internal/tip"
 )
 
 type testCaseItem struct {
-	path         string
-	name         string
-	isUnresolved bool
+	path          string
+	name          string
+	nameForView   string // name with subtest names as written in the source, if it differs from name
+	kind          tip.TestKind
+	hasOutput     bool
+	isUnresolved  bool
+	pos           tip.Position
+	record        *tip.TestRecord
+	marked        bool
+	fileHasErrors bool
 }
 
 var _ list.Item = (*testCaseItem)(nil)
 
-func toTestCaseItems(tests map[string][]*tip.TestFunction) []list.Item {
+func toTestCaseItems(tests map[string][]*tip.TestFunction, records *tip.TestRecords) []list.Item {
 	items := make([]list.Item, 0)
 	for path, tfs := range tests {
 		for _, tf := range tfs {
+			var tfItems []list.Item
 			if len(tf.Subs) == 0 {
 				item := &testCaseItem{
 					path:         path,
 					name:         tf.Name,
+					kind:         tf.Kind,
+					hasOutput:    tf.HasOutput,
 					isUnresolved: false,
+					pos:          tf.Pos,
 				}
-				items = append(items, item)
+				tfItems = []list.Item{item}
 			} else {
-				items = append(items, toTestCaseItemsFromSubTests(tf.Subs, path, tf.Name)...)
+				tfItems = toTestCaseItemsFromSubTests(tf.Subs, path, tf.Name, tf.Name, tf.Kind)
 			}
+			for _, item := range tfItems {
+				item.(*testCaseItem).fileHasErrors = tf.FileHasErrors
+			}
+			items = append(items, tfItems...)
 		}
 	}
 	slices.SortStableFunc(items, func(a, b list.Item) int {
 		return cmp.Compare(a.(*testCaseItem).path, b.(*testCaseItem).path)
 	})
+	updateTestCaseItemRecords(items, records)
 	return items
 }
 
-func toTestCaseItemsFromSubTests(ss []*tip.SubTest, path, base string) []list.Item {
+func updateTestCaseItemRecords(items []list.Item, records *tip.TestRecords) {
+	for _, item := range items {
+		i := item.(*testCaseItem)
+		i.record = records.Get(i.target())
+	}
+}
+
+func toTestCaseItemsFromSubTests(ss []*tip.SubTest, path, base, baseForView string, kind tip.TestKind) []list.Item {
 	items := make([]list.Item, 0)
 	for _, s := range ss {
-		subName := s.Name
+		subName, subNameForView := s.Name, s.NameForView()
 		if !s.Resolved {
-			subName = tip.UnresolvedTestCaseName
+			subName, subNameForView = tip.UnresolvedTestCaseName, tip.UnresolvedTestCaseName
 		}
 		name := base + "/" + subName
+		nameForView := baseForView + "/" + subNameForView
 		if len(s.Subs) == 0 {
 			item := &testCaseItem{
 				path:         path,
 				name:         name,
+				kind:         kind,
 				isUnresolved: !s.Resolved,
+				pos:          s.Pos,
+			}
+			if nameForView != name {
+				item.nameForView = nameForView
 			}
 			items = append(items, item)
 		} else {
-			items = append(items, toTestCaseItemsFromSubTests(s.Subs, path, name)...)
+			items = append(items, toTestCaseItemsFromSubTests(s.Subs, path, name, nameForView, kind)...)
 		}
 	}
 	return items
 }
 
 func (i *testCaseItem) FilterValue() string {
+	return i.title()
+}
+
+// title returns the name shown in the list, which go test may report differently.
+func (i *testCaseItem) title() string {
+	if i.nameForView != "" {
+		return i.nameForView
+	}
 	return i.name
 }
 
+func (i *testCaseItem) target() *tip.Target {
+	return tip.NewTarget(i.path, i.name, i.kind, i.isUnresolved)
+}
+
+// location returns file:line of the test if known, or the test file path otherwise.
+func (i *testCaseItem) location() string {
+	if i.pos.IsValid() {
+		return i.pos.String()
+	}
+	return i.path
+}
+
+func (i *testCaseItem) label() string {
+	label := kindLabel(i.kind)
+	if i.kind == tip.TestKindExample && !i.hasOutput {
+		// go test compiles examples without an output comment but never runs them
+		label = "[example: no output, not run] "
+	}
+	if i.fileHasErrors {
+		label = fileHasErrorsLabel + label
+	}
+	return label
+}
+
 type historyItem struct {
-	path         string
-	name         string
-	nameForView  string // name adjusted for view (e.g., with asterisk for prefix)
-	isUnresolved bool
-	runAt        string
+	path        string
+	nameForView string // name adjusted for view (e.g., with asterisk for prefix)
+	kind        tip.TestKind
+	runAt       string
+	record      *tip.TestRecord
+	targets     []*tip.Target
 }
 
 var _ list.Item = (*historyItem)(nil)
 
-func toHistoryItems(histories *tip.Histories, dateFormat string) []list.Item {
+func toHistoryItems(histories *tip.Histories, records *tip.TestRecords, dateFormat string) []list.Item {
 	items := make([]list.Item, 0)
 	for _, h := range histories.Histories {
+		if h.IsSet() {
+			items = append(items, toHistorySetItem(h, records, dateFormat))
+			continue
+		}
 		nameForView := h.TestNamePattern
 		if h.IsPrefix {
 			nameForView += "*"
 		}
+		if h.IsFuzzing {
+			nameForView += " (fuzzing)"
+		}
 		item := &historyItem{
-			path:         h.Path,
-			name:         h.TestNamePattern,
-			nameForView:  nameForView,
-			isUnresolved: h.IsPrefix,
-			runAt:        h.RunAt.Format(dateFormat),
+			path:        h.Path,
+			nameForView: nameForView,
+			kind:        h.Kind,
+			runAt:       h.RunAt.Format(dateFormat),
+			record:      records.Get(h.ToTarget()),
+			targets:     h.ToTargets(),
 		}
 		items = append(items, item)
 	}
 	return items
 }
 
+func toHistorySetItem(h *tip.History, records *tip.TestRecords, dateFormat string) *historyItem {
+	targets := h.ToTargets()
+	return &historyItem{
+		nameForView: targetNames(targets),
+		runAt:       h.RunAt.Format(dateFormat),
+		record:      setRecord(targets, records),
+		targets:     targets,
+	}
+}
+
+// setRecord summarizes the latest outcomes of the targets run together.
+// It returns nil unless all of them have been run.
+func setRecord(targets []*tip.Target, records *tip.TestRecords) *tip.TestRecord {
+	summary := &tip.TestRecord{Status: tip.TestStatusSkip}
+	for _, target := range targets {
+		record := records.Get(target)
+		if record == nil {
+			return nil
+		}
+		switch {
+		case record.Status == tip.TestStatusFail:
+			summary.Status = tip.TestStatusFail
+		case record.Status == tip.TestStatusPass && summary.Status == tip.TestStatusSkip:
+			summary.Status = tip.TestStatusPass
+		}
+		summary.Elapsed += record.Elapsed
+		if record.RunAt.After(summary.RunAt) {
+			summary.RunAt = record.RunAt
+		}
+	}
+	return summary
+}
+
 func (i *historyItem) FilterValue() string {
 	return i.nameForView
 }
+
+func (i *historyItem) isSet() bool {
+	return len(i.targets) > 1
+}
+
+// location returns the test file path, or the packages of the targets if the path is unknown or the history is a set.
+func (i *historyItem) location() string {
+	if !i.isSet() && i.path != "" {
+		return i.path
+	}
+	return targetPackages(i.targets)
+}
+
+// targetNames returns the names of the targets for display.
+func targetNames(targets []*tip.Target) string {
+	names := make([]string, 0, len(targets))
+	for _, target := range targets {
+		name := target.TestNamePattern
+		if target.IsPrefix {
+			name += "*"
+		}
+		names = append(names, name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// targetPackages returns the distinct packages of the targets for display.
+func targetPackages(targets []*tip.Target) string {
+	packages := make([]string, 0)
+	for _, target := range targets {
+		if !slices.Contains(packages, target.PackageName) {
+			packages = append(packages, target.PackageName)
+		}
+	}
+	return strings.Join(packages, ", ")
+}
+
+// fileHasErrorsLabel marks the tests found in a file with syntax errors, which may be incomplete or fail to compile.
+const fileHasErrorsLabel = "[file has errors] "
+
+// kindLabel returns a short label to distinguish non-test kinds in the list.
+func kindLabel(kind tip.TestKind) string {
+	switch kind {
+	case tip.TestKindBenchmark:
+		return "[bench] "
+	case tip.TestKindFuzz:
+		return "[fuzz] "
+	case tip.TestKindExample:
+		return "[example] "
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,176 @@
+package ui
+
+import (
+	"fmt"
+	"go/scanner"
+	"go/token"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/lusingander/gotip/internal/tip"
+)
+
+var (
+	previewKeywordColor = lipgloss.Color("#CE3262")
+	previewStringColor  = lipgloss.Color("#00A29C")
+	previewNumberColor  = lipgloss.Color("#FDDD00")
+	previewCommentColor = lipgloss.Color("#777777")
+	previewLineNumColor = lipgloss.Color("#4D4D4D")
+)
+
+var (
+	previewKeywordStyle = lipgloss.NewStyle().Foreground(previewKeywordColor)
+	previewStringStyle  = lipgloss.NewStyle().Foreground(previewStringColor)
+	previewNumberStyle  = lipgloss.NewStyle().Foreground(previewNumberColor)
+	previewCommentStyle = lipgloss.NewStyle().Foreground(previewCommentColor)
+
+	previewLineNumStyle         = lipgloss.NewStyle().Foreground(previewLineNumColor)
+	previewSelectedLineNumStyle = lipgloss.NewStyle().Foreground(listSelectedColor).Bold(true)
+	previewMessageStyle         = lipgloss.NewStyle().Foreground(listDimmedTitleColor).Padding(0, 1)
+
+	previewStyle = lipgloss.NewStyle().
+			Border(lipgloss.NormalBorder(), false, false, false, true).
+			BorderForeground(borderColor)
+)
+
+const (
+	previewTabWidth = 4
+	// number of lines shown above the previewed line
+	previewContextLines = 3
+)
+
+// previewPane shows the source around the position of the highlighted test.
+type previewPane struct {
+	w, h int
+	// highlighted lines of the files, kept until they may have been edited
+	files map[string]*previewFile
+}
+
+type previewFile struct {
+	lines []string
+	err   error
+}
+
+func newPreviewPane() previewPane {
+	return previewPane{files: make(map[string]*previewFile)}
+}
+
+func (p *previewPane) setSize(w, h int) {
+	p.w, p.h = w, h
+}
+
+func (p *previewPane) view(pos tip.Position) string {
+	contentWidth := p.w - previewStyle.GetHorizontalFrameSize()
+	style := previewStyle.Width(contentWidth).Height(p.h).MaxHeight(p.h)
+	if !pos.IsValid() {
+		return style.Render(previewMessageStyle.Render("No source to preview"))
+	}
+	file := p.file(pos.File)
+	if file.err != nil {
+		return style.Render(previewMessageStyle.Render(ansi.Truncate(file.err.Error(), contentWidth-2, ellipsis)))
+	}
+
+	// long paths are truncated from the left to keep the file name and the line number
+	title := pos.String()
+	if over := lipgloss.Width(title) + 1 - contentWidth; over > 0 {
+		title = ansi.TruncateLeft(title, over+lipgloss.Width(ellipsis), ellipsis)
+	}
+	lines := []string{selectedPathStyle.Render(" " + title)}
+
+	numWidth := len(fmt.Sprint(len(file.lines)))
+	first := max(pos.Line-previewContextLines, 1)
+	for n := first; n <= len(file.lines) && len(lines) < p.h; n++ {
+		num := fmt.Sprintf(" %*d ", numWidth, n)
+		if n == pos.Line {
+			num = previewSelectedLineNumStyle.Render(num)
+		} else {
+			num = previewLineNumStyle.Render(num)
+		}
+		line := ansi.Truncate(file.lines[n-1], contentWidth-lipgloss.Width(num), "")
+		lines = append(lines, num+line)
+	}
+	return style.Render(strings.Join(lines, "\n"))
+}
+
+// clearCache forgets the read files, which may have been edited.
+func (p *previewPane) clearCache() {
+	clear(p.files)
+}
+
+func (p *previewPane) file(path string) *previewFile {
+	if f, ok := p.files[path]; ok {
+		return f
+	}
+	f := &previewFile{}
+	src, err := os.ReadFile(path)
+	if err != nil {
+		f.err = err
+	} else {
+		f.lines = highlightGo(src)
+	}
+	p.files[path] = f
+	return f
+}
+
+// highlightGo returns the lines of the Go source with keywords, literals and comments colored.
+// Tabs are expanded since their width cannot be measured when the lines are truncated.
+func highlightGo(src []byte) []string {
+	src = []byte(strings.ReplaceAll(string(src), "\t", strings.Repeat(" ", previewTabWidth)))
+
+	fset := token.NewFileSet()
+	file := fset.AddFile("", fset.Base(), len(src))
+	var s scanner.Scanner
+	// errors are ignored, and the rest of the source is shown as it is
+	s.Init(file, src, nil, scanner.ScanComments)
+
+	var b strings.Builder
+	last := 0
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		if tok == token.SEMICOLON && lit == "\n" {
+			// inserted automatically at the end of the line
+			continue
+		}
+		start := file.Offset(pos)
+		end := start + len(tok.String())
+		if lit != "" {
+			end = start + len(lit)
+		}
+		if start < last || end > len(src) {
+			continue
+		}
+		b.Write(src[last:start])
+		b.WriteString(renderToken(tok, string(src[start:end])))
+		last = end
+	}
+	b.Write(src[last:])
+
+	return strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n")
+}
+
+func renderToken(tok token.Token, text string) string {
+	var style lipgloss.Style
+	switch {
+	case tok.IsKeyword():
+		style = previewKeywordStyle
+	case tok == token.STRING || tok == token.CHAR:
+		style = previewStringStyle
+	case tok == token.INT || tok == token.FLOAT || tok == token.IMAG:
+		style = previewNumberStyle
+	case tok == token.COMMENT:
+		style = previewCommentStyle
+	default:
+		return text
+	}
+	// render each line separately so that the lines can be split afterwards
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = style.Render(line)
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,50 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/x/ansi"
+	"github.com/lusingander/gotip/internal/tip"
+)
+
+func TestHighlightGoKeepsSource(t *testing.T) {
+	src := "package foo\n\n/* multi\nline */\nfunc TestA(t *testing.T) {\n\ts := `raw\nstring` // comment\n\t_ = 1.5\n}\n"
+
+	var got []string
+	for _, line := range highlightGo([]byte(src)) {
+		got = append(got, ansi.Strip(line))
+	}
+	want := strings.Split(strings.TrimSuffix(strings.ReplaceAll(src, "\t", "    "), "\n"), "\n")
+	if !slices.Equal(got, want) {
+		t.Errorf("lines = %q, want %q", got, want)
+	}
+}
+
+func TestPreviewPaneView(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "a_test.go")
+	src := strings.Repeat("// line\n", 40)
+	if err := os.WriteFile(path, []byte(src), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	p := newPreviewPane()
+	p.setSize(40, 5)
+
+	pos := tip.Position{File: path, Line: 33, Column: 3}
+	lines := strings.Split(ansi.Strip(p.view(pos)), "\n")
+	if len(lines) != 5 {
+		t.Fatalf("lines len = %d, want 5", len(lines))
+	}
+	// the title and the lines from a few lines above the position
+	if !strings.Contains(lines[0], "a_test.go:33") || !strings.Contains(lines[1], " 30 ") {
+		t.Errorf("view = %q, want the title and lines from 30", lines)
+	}
+
+	if got := ansi.Strip(p.view(tip.Position{})); !strings.Contains(got, "No source to preview") {
+		t.Errorf("view = %q, want no source message", got)
+	}
+}
@@ -0,0 +1,351 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/lusingander/gotip/internal/command"
+	"github.com/lusingander/gotip/internal/tip"
+	"github.com/lusingander/gotip/internal/watch"
+)
+
+var (
+	resultPassedStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("#00A29C")).Bold(true)
+	resultFailedStyle    = lipgloss.NewStyle().Foreground(errorColor).Bold(true)
+	resultSeparatorStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#777777"))
+)
+
+// Loop configures the loop mode, where the selected test is run inside the UI
+// and the picker is shown again after checking the result.
+type Loop struct {
+	// Run runs the targets and writes all of their output to w.
+	// It is called outside the UI goroutine, so it must not modify the histories and the records.
+	Run func(ctx context.Context, targets []*tip.Target, w io.Writer) (*tip.RunResult, error)
+	// Save records the run in the histories and the records, in the UI goroutine which reads them.
+	Save func(targets []*tip.Target, result *tip.RunResult) error
+	// Histories returns the histories to display after a run.
+	Histories func() *tip.Histories
+	// Records returns the latest outcomes of tests to display after a run.
+	Records func() *tip.TestRecords
+	// Watch starts watching the files the targets depend on.
+	Watch func(targets []*tip.Target) (*watch.Watcher, error)
+	// AutoWatch starts watching whenever tests are run from the list.
+	AutoWatch bool
+}
+
+type testRunOutputMsg struct {
+	output string
+}
+
+type testRunFinishedMsg struct {
+	targets []*tip.Target
+	result  *tip.RunResult
+	err     error
+}
+
+type watchStartedMsg struct {
+	watcher *watch.Watcher
+	err     error
+}
+
+type watchChangedMsg struct {
+	watcher *watch.Watcher
+	files   []string
+	closed  bool
+}
+
+type resultPane struct {
+	viewport viewport.Model
+	targets  []*tip.Target
+	output   []byte
+	running  bool
+	canceled bool
+	exitCode int
+	err      error
+	cancel   context.CancelFunc
+	msgs     chan tea.Msg
+
+	watcher       *watch.Watcher
+	watchStarting bool
+	watchPending  bool // files changed while running
+}
+
+func newResultPane() resultPane {
+	return resultPane{
+		viewport: viewport.New(0, 0),
+	}
+}
+
+func (p *resultPane) setSize(w, h int) {
+	p.viewport.Width = w
+	p.viewport.Height = h
+}
+
+// start runs the targets. The output of the previous run is kept if keepOutput is true, as in watch mode.
+func (p *resultPane) start(targets []*tip.Target, run func(ctx context.Context, targets []*tip.Target, w io.Writer) (*tip.RunResult, error), keepOutput bool) tea.Cmd {
+	ctx, cancel := context.WithCancel(context.Background())
+	msgs := make(chan tea.Msg)
+
+	p.targets = targets
+	p.running = true
+	p.canceled = false
+	p.exitCode = 0
+	p.err = nil
+	p.cancel = cancel
+	p.msgs = msgs
+	if !keepOutput {
+		p.output = nil
+		p.viewport.SetContent("")
+		p.viewport.GotoTop()
+	}
+
+	go func() {
+		defer cancel()
+		result, err := run(ctx, targets, msgWriter{msgs})
+		msgs <- testRunFinishedMsg{targets: targets, result: result, err: err}
+	}()
+	return p.waitForMsg()
+}
+
+func (p *resultPane) waitForMsg() tea.Cmd {
+	msgs := p.msgs
+	return func() tea.Msg {
+		return <-msgs
+	}
+}
+
+func (p *resultPane) appendOutput(output string) {
+	// keep following the output unless the user scrolled up
+	follow := p.viewport.AtBottom()
+	p.output = append(p.output, output...)
+	p.viewport.SetContent(strings.TrimSuffix(string(p.output), "\n"))
+	if follow {
+		p.viewport.GotoBottom()
+	}
+}
+
+func (p *resultPane) finish(exitCode int, err error) {
+	p.running = false
+	p.exitCode = exitCode
+	p.err = err
+	p.cancel = nil
+}
+
+func (p *resultPane) stopWatch() {
+	if p.watcher != nil {
+		p.watcher.Close()
+		p.watcher = nil
+	}
+	p.watchPending = false
+}
+
+func (p *resultPane) waitForChange() tea.Cmd {
+	w := p.watcher
+	return func() tea.Msg {
+		files, ok := <-w.Changes()
+		return watchChangedMsg{watcher: w, files: files, closed: !ok}
+	}
+}
+
+func (p *resultPane) cancelRun() {
+	if p.cancel != nil {
+		p.canceled = true
+		p.cancel()
+	}
+}
+
+func (p resultPane) statusView() string {
+	if p.watcher != nil && !p.running {
+		return p.resultStatusView() + " (watching)"
+	}
+	return p.resultStatusView()
+}
+
+func (p resultPane) resultStatusView() string {
+	switch {
+	case p.running && p.canceled:
+		return "Canceling..."
+	case p.running:
+		return "Running..."
+	case p.canceled:
+		return resultFailedStyle.Render("Canceled")
+	case p.err != nil:
+		return resultFailedStyle.Render("Error: " + p.err.Error())
+	case p.exitCode == 0:
+		return resultPassedStyle.Render("Passed")
+	default:
+		return resultFailedStyle.Render(fmt.Sprintf("Failed (exit code %d)", p.exitCode))
+	}
+}
+
+// msgWriter sends everything written to it to the UI as testRunOutputMsg.
+type msgWriter struct {
+	msgs chan<- tea.Msg
+}
+
+func (w msgWriter) Write(p []byte) (int, error) {
+	w.msgs <- testRunOutputMsg{output: string(p)}
+	return len(p), nil
+}
+
+func (m *model) startRun(targets []*tip.Target) tea.Cmd {
+	if len(targets) == 0 || m.result.running {
+		return nil
+	}
+	m.showResult = true
+	cmd := m.result.start(targets, m.loop.Run, false)
+	if m.loop.AutoWatch && m.result.watcher == nil {
+		return tea.Batch(cmd, m.startWatch())
+	}
+	return cmd
+}
+
+// rerunOnChange runs the targets again after the files changed, keeping the output of the previous runs.
+func (m *model) rerunOnChange(files []string) tea.Cmd {
+	m.result.appendOutput("\n" + resultSeparatorStyle.Render(command.WatchSeparator(files, m.result.viewport.Width)) + "\n\n")
+	return m.result.start(m.result.targets, m.loop.Run, true)
+}
+
+func (m *model) closeResult() {
+	m.showResult = false
+	m.result.stopWatch()
+}
+
+func (m *model) toggleWatch() tea.Cmd {
+	if m.result.watcher != nil {
+		m.result.stopWatch()
+		return nil
+	}
+	return m.startWatch()
+}
+
+func (m *model) startWatch() tea.Cmd {
+	if m.loop.Watch == nil || m.result.watchStarting || len(m.result.targets) == 0 {
+		return nil
+	}
+	m.result.watchStarting = true
+	watchFn, targets := m.loop.Watch, m.result.targets
+	return func() tea.Msg {
+		w, err := watchFn(targets)
+		return watchStartedMsg{watcher: w, err: err}
+	}
+}
+
+func (m model) handleWatchStarted(msg watchStartedMsg) (tea.Model, tea.Cmd) {
+	m.result.watchStarting = false
+	if msg.err != nil {
+		m.result.appendOutput("\n" + resultFailedStyle.Render("Failed to watch: "+msg.err.Error()) + "\n")
+		return m, nil
+	}
+	if !m.showResult {
+		// the result pane was closed while starting
+		msg.watcher.Close()
+		return m, nil
+	}
+	m.result.watcher = msg.watcher
+	return m, m.result.waitForChange()
+}
+
+func (m model) handleWatchChanged(msg watchChangedMsg) (tea.Model, tea.Cmd) {
+	if msg.watcher != m.result.watcher {
+		// notification from a watcher which is already stopped
+		return m, nil
+	}
+	if msg.closed {
+		m.result.watcher = nil
+		return m, nil
+	}
+	if m.result.running {
+		m.result.watchPending = true
+		return m, m.result.waitForChange()
+	}
+	return m, tea.Batch(m.rerunOnChange(msg.files), m.result.waitForChange())
+}
+
+// finishRun handles the end of a run, and runs the targets again if files changed while watching.
+func (m *model) finishRun(msg testRunFinishedMsg) tea.Cmd {
+	exitCode, err := 1, msg.err
+	if msg.result != nil {
+		exitCode = msg.result.ExitCode
+		if err == nil && m.loop.Save != nil {
+			err = m.loop.Save(msg.targets, msg.result)
+		}
+	}
+	m.result.finish(exitCode, err)
+	if m.result.watcher == nil {
+		return nil
+	}
+	m.result.appendOutput("\n" + m.result.resultStatusView() + " " +
+		resultSeparatorStyle.Render(time.Now().Format(time.TimeOnly)+"  Watching for changes...") + "\n")
+	if m.result.watchPending {
+		m.result.watchPending = false
+		return m.rerunOnChange(nil)
+	}
+	return nil
+}
+
+func (m model) updateResult(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		if m.result.running {
+			m.result.cancelRun()
+			return m, nil
+		}
+		return m, tea.Quit
+	case "esc", "q", "backspace", "ctrl+h":
+		if !m.result.running {
+			m.closeResult()
+		}
+		return m, nil
+	case "r":
+		if !m.result.running {
+			return m, m.result.start(m.result.targets, m.loop.Run, false)
+		}
+		return m, nil
+	case "w":
+		return m, m.toggleWatch()
+	}
+	newViewport, cmd := m.result.viewport.Update(msg)
+	m.result.viewport = newViewport
+	return m, cmd
+}
+
+func (m model) resultView() string {
+	var headerContent string
+	if targets := m.result.targets; len(targets) > 0 {
+		name := targetNames(targets)
+		nameWidth := m.w - headerStyle.GetHorizontalFrameSize() - lipgloss.Width("Result: ")
+		name = ansi.Truncate(name, nameWidth, ellipsis)
+		headerContent = selectedLabelStyle.Render("Result: ") + selectedNameStyle.Render(name) + "\n" +
+			selectedLabelStyle.Render(" Status: ") + m.result.statusView()
+	} else {
+		headerContent = "\n"
+	}
+	header := headerStyle.Width(m.w).Render(headerContent)
+
+	watchHelp := "w: Watch"
+	if m.result.watcher != nil {
+		watchHelp = "w: Stop watching"
+	}
+	var footerStatus string
+	if !m.result.running {
+		footerStatus = footerMsgStyle.Render("r: Rerun, " + watchHelp + ", Esc: Back to list")
+	} else if m.loop.Watch != nil {
+		footerStatus = footerMsgStyle.Render(watchHelp)
+	}
+	footerView := footerDividerStyle.Render(" | ") + footerMsgStyle.Render("Result   ")
+	footerSpaceWidth := max(m.w-lipgloss.Width(footerStatus)-lipgloss.Width(footerView)-2 /* padding */, 0)
+	footerSpace := strings.Repeat(" ", footerSpaceWidth)
+	footer := footerStyle.Width(m.w).Render(footerStatus + footerSpace + footerView)
+
+	content := lipgloss.NewStyle().Height(m.result.viewport.Height).Render(m.result.viewport.View())
+
+	return lipgloss.JoinVertical(lipgloss.Left, header, content, footer)
+}
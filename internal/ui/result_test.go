@@ -0,0 +1,104 @@
+package ui
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/lusingander/gotip/internal/tip"
+)
+
+func TestLoopRunShowsResultAndReturnsToList(t *testing.T) {
+	histories := &tip.Histories{Histories: []*tip.History{}}
+	records := &tip.TestRecords{Records: map[string]*tip.TestRecord{}}
+	loop := &Loop{
+		Run: func(ctx context.Context, targets []*tip.Target, w io.Writer) (*tip.RunResult, error) {
+			io.WriteString(w, "--- FAIL: TestA\n")
+			return &tip.RunResult{
+				Tests:    []*tip.TestResult{{Package: ".", Name: "TestA", Status: tip.TestStatusFail}},
+				ExitCode: 1,
+			}, nil
+		},
+		Save: func(targets []*tip.Target, result *tip.RunResult) error {
+			histories.Add(targets[0], 10)
+			records.Add(result)
+			return nil
+		},
+		Histories: func() *tip.Histories {
+			return histories
+		},
+		Records: func() *tip.TestRecords {
+			return records
+		},
+	}
+	items := []list.Item{&testCaseItem{path: "./a_test.go", name: "TestA"}}
+	conf := &tip.Config{History: tip.HistoryConfig{DateFormat: "2006-01-02"}}
+	var m tea.Model = newModel(items, []list.Item{}, nil, records, conf, loop, allView, fuzzyMatchFilterType)
+	m, _ = m.Update(tea.WindowSizeMsg{Width: 80, Height: 24})
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyDown}) // select the first item
+
+	m, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if !m.(model).showResult {
+		t.Fatal("result pane is not shown after enter")
+	}
+	// drain messages from the running test until it finishes
+	for cmd != nil {
+		m, cmd = m.Update(cmd())
+	}
+
+	got := m.(model)
+	if got.result.running {
+		t.Error("result is still running")
+	}
+	if got.result.exitCode != 1 {
+		t.Errorf("exit code = %d, want 1", got.result.exitCode)
+	}
+	if string(got.result.output) != "--- FAIL: TestA\n" {
+		t.Errorf("output = %q, want %q", got.result.output, "--- FAIL: TestA\n")
+	}
+	if len(got.historyList.Items()) != 1 {
+		t.Errorf("history items len = %d, want 1", len(got.historyList.Items()))
+	}
+	if r := got.allList.Items()[0].(*testCaseItem).record; r == nil || r.Status != tip.TestStatusFail {
+		t.Errorf("test item record = %+v, want failed", r)
+	}
+	if r := got.historyList.Items()[0].(*historyItem).record; r == nil || r.Status != tip.TestStatusFail {
+		t.Errorf("history item record = %+v, want failed", r)
+	}
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if m.(model).showResult {
+		t.Error("result pane is still shown after esc")
+	}
+	if m.(model).retTargets != nil {
+		t.Error("target is returned in loop mode")
+	}
+}
+
+func TestRerunFailedReturnsFailedTargets(t *testing.T) {
+	records := &tip.TestRecords{Records: map[string]*tip.TestRecord{}}
+	conf := &tip.Config{History: tip.HistoryConfig{DateFormat: "2006-01-02"}}
+	var m tea.Model = newModel([]list.Item{}, []list.Item{}, nil, records, conf, nil, allView, fuzzyMatchFilterType)
+	m, _ = m.Update(tea.WindowSizeMsg{Width: 80, Height: 24})
+
+	m, cmd := m.Update(tea.KeyMsg{Type: tea.KeyCtrlR})
+	if cmd != nil {
+		t.Fatal("quit without failed tests")
+	}
+	if m.(model).statusMsgType != noFailedTestsStatusMsgType {
+		t.Errorf("status = %v, want no failed tests", m.(model).statusMsgType)
+	}
+
+	records.Add(&tip.RunResult{Tests: []*tip.TestResult{
+		{Package: "./foo", Name: "TestA/x", Status: tip.TestStatusFail},
+		{Package: "./foo", Name: "TestA", Status: tip.TestStatusFail},
+		{Package: "./bar", Name: "TestB", Status: tip.TestStatusFail},
+	}})
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyCtrlR})
+	got := m.(model).retTargets
+	if len(got) != 2 || got[0].TestNamePattern != "TestA/x" || got[1].PackageName != "./bar" {
+		t.Errorf("targets = %+v, want TestA/x and TestB", got)
+	}
+}
@@ -0,0 +1,233 @@
+package ui
+
+import (
+	"cmp"
+	"path/filepath"
+	"slices"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/lusingander/gotip/internal/tip"
+)
+
+type treeNodeType int
+
+const (
+	packageTreeNode treeNodeType = iota
+	fileTreeNode
+	testTreeNode
+)
+
+type treeNode struct {
+	nodeType treeNodeType
+	label    string
+	key      string // path from the root joined with slashes, which ends with the label
+	depth    int
+	parent   *treeNode
+	children []*treeNode
+	expanded bool
+
+	// test file path, and the fields below are set for test nodes only, except for pos of file nodes
+	path         string
+	name         string // full test name such as TestA/sub
+	kind         tip.TestKind
+	hasOutput    bool
+	isUnresolved bool
+	pos          tip.Position
+	record       *tip.TestRecord
+
+	// whether the file has syntax errors, which is set for file nodes only
+	fileHasErrors bool
+}
+
+var _ list.Item = (*treeNode)(nil)
+
+func (n *treeNode) FilterValue() string {
+	return n.key
+}
+
+func (n *treeNode) hasChildren() bool {
+	return len(n.children) > 0
+}
+
+// targets returns the targets to run all tests under the node.
+func (n *treeNode) targets() []*tip.Target {
+	switch n.nodeType {
+	case packageTreeNode:
+		// all tests in the package
+		return []*tip.Target{tip.NewTarget(n.path, "", tip.TestKindTest, true)}
+	case fileTreeNode:
+		// go test cannot select a file, so all tests in it are selected instead
+		targets := make([]*tip.Target, 0, len(n.children))
+		for _, c := range n.children {
+			targets = append(targets, c.targets()...)
+		}
+		return targets
+	default:
+		if n.hasChildren() {
+			// same as selecting the parent test group with backspace
+			return []*tip.Target{tip.NewTarget(n.path, n.name+"/", n.kind, true)}
+		}
+		return []*tip.Target{tip.NewTarget(n.path, n.name, n.kind, n.isUnresolved)}
+	}
+}
+
+func toTreeNodes(tests map[string][]*tip.TestFunction, records *tip.TestRecords) []*treeNode {
+	paths := make([]string, 0, len(tests))
+	for path := range tests {
+		paths = append(paths, path)
+	}
+	slices.Sort(paths)
+
+	roots := make([]*treeNode, 0)
+	packages := make(map[string]*treeNode)
+	for _, path := range paths {
+		tfs := tests[path]
+		if len(tfs) == 0 {
+			continue
+		}
+		pkgName := tip.NewTarget(path, "", tip.TestKindTest, true).PackageName
+		pkg, ok := packages[pkgName]
+		if !ok {
+			pkg = &treeNode{
+				nodeType: packageTreeNode,
+				label:    pkgName,
+				key:      pkgName,
+				path:     path,
+			}
+			packages[pkgName] = pkg
+			roots = append(roots, pkg)
+		}
+		file := pkg.addChild(&treeNode{
+			nodeType: fileTreeNode,
+			label:    filepath.Base(path),
+			path:     path,
+			// the top of the file is shown in the preview and opened in the editor
+			pos: tip.Position{File: path, Line: 1},
+		})
+		for _, tf := range tfs {
+			file.fileHasErrors = file.fileHasErrors || tf.FileHasErrors
+			test := file.addChild(&treeNode{
+				nodeType:  testTreeNode,
+				label:     tf.Name,
+				path:      path,
+				name:      tf.Name,
+				kind:      tf.Kind,
+				hasOutput: tf.HasOutput,
+				pos:       tf.Pos,
+			})
+			test.addSubTests(tf.Subs)
+		}
+	}
+	slices.SortStableFunc(roots, func(a, b *treeNode) int {
+		return cmp.Compare(a.label, b.label)
+	})
+	updateTreeNodeRecords(allTreeNodes(roots), records)
+	return roots
+}
+
+func (n *treeNode) addChild(child *treeNode) *treeNode {
+	child.parent = n
+	child.depth = n.depth + 1
+	child.key = n.key + "/" + child.label
+	n.children = append(n.children, child)
+	return child
+}
+
+func (n *treeNode) addSubTests(subs []*tip.SubTest) {
+	for _, s := range subs {
+		name, label := s.Name, s.NameForView()
+		if !s.Resolved {
+			name, label = tip.UnresolvedTestCaseName, tip.UnresolvedTestCaseName
+		}
+		child := n.addChild(&treeNode{
+			nodeType:     testTreeNode,
+			label:        label,
+			path:         n.path,
+			name:         n.name + "/" + name,
+			kind:         n.kind,
+			isUnresolved: !s.Resolved,
+			pos:          s.Pos,
+		})
+		child.addSubTests(s.Subs)
+	}
+}
+
+func updateTreeNodeRecords(nodes []list.Item, records *tip.TestRecords) {
+	for _, item := range nodes {
+		n := item.(*treeNode)
+		if n.nodeType == testTreeNode {
+			n.record = records.Get(n.targets()[0])
+		}
+	}
+}
+
+// allTreeNodes returns all nodes in depth-first order, regardless of whether they are expanded.
+func allTreeNodes(roots []*treeNode) []list.Item {
+	items := make([]list.Item, 0)
+	var walk func(nodes []*treeNode)
+	walk = func(nodes []*treeNode) {
+		for _, n := range nodes {
+			items = append(items, n)
+			walk(n.children)
+		}
+	}
+	walk(roots)
+	return items
+}
+
+// visibleTreeNodes returns the nodes whose ancestors are all expanded, in depth-first order.
+func visibleTreeNodes(roots []*treeNode) []list.Item {
+	items := make([]list.Item, 0)
+	var walk func(nodes []*treeNode)
+	walk = func(nodes []*treeNode) {
+		for _, n := range nodes {
+			items = append(items, n)
+			if n.expanded {
+				walk(n.children)
+			}
+		}
+	}
+	walk(roots)
+	return items
+}
+
+// treeFilter wraps the filter so that ancestors of matched nodes are kept visible.
+// The nodes are the items of the list, whose keys are the targets in the same order.
+// Ancestors are found by the parents of the nodes, since test names may contain slashes as the keys do.
+func treeFilter(filter list.FilterFunc, nodes []list.Item) list.FilterFunc {
+	return func(term string, targets []string) []list.Rank {
+		ranks := filter(term, targets)
+		if len(nodes) != len(targets) {
+			return ranks
+		}
+		indexes := make(map[*treeNode]int, len(nodes))
+		for i, item := range nodes {
+			indexes[item.(*treeNode)] = i
+		}
+
+		matched := make(map[int]list.Rank)
+		keep := make([]bool, len(targets))
+		for _, rank := range ranks {
+			matched[rank.Index] = rank
+			keep[rank.Index] = true
+			for p := nodes[rank.Index].(*treeNode).parent; p != nil; p = p.parent {
+				if i, ok := indexes[p]; ok {
+					keep[i] = true
+				}
+			}
+		}
+
+		kept := make([]list.Rank, 0)
+		for i := range targets {
+			if !keep[i] {
+				continue
+			}
+			if rank, ok := matched[i]; ok {
+				kept = append(kept, rank)
+			} else {
+				kept = append(kept, list.Rank{Index: i})
+			}
+		}
+		return kept
+	}
+}
@@ -0,0 +1,155 @@
+package ui
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/lusingander/gotip/internal/tip"
+)
+
+func testTreeRoots() []*treeNode {
+	tests := map[string][]*tip.TestFunction{
+		"./foo/foo_test.go": {
+			{Name: "TestA", Kind: tip.TestKindTest, Subs: []*tip.SubTest{
+				{Name: "x", Resolved: true},
+				{Name: "y", Resolved: true},
+			}},
+			{Name: "TestB", Kind: tip.TestKindTest},
+		},
+		"./foo/bar/bar_test.go": {
+			{Name: "TestC", Kind: tip.TestKindTest},
+		},
+	}
+	return toTreeNodes(tests, nil)
+}
+
+func treeKeys(items []list.Item) []string {
+	keys := make([]string, 0, len(items))
+	for _, item := range items {
+		keys = append(keys, item.(*treeNode).key)
+	}
+	return keys
+}
+
+func TestTreeNodeTargets(t *testing.T) {
+	nodes := allTreeNodes(testTreeRoots())
+	tests := []struct {
+		key  string
+		want []string
+	}{
+		{key: "./foo", want: []string{"./foo  true"}},
+		{key: "./foo/foo_test.go", want: []string{"./foo TestA/ true", "./foo TestB false"}},
+		{key: "./foo/foo_test.go/TestA", want: []string{"./foo TestA/ true"}},
+		{key: "./foo/foo_test.go/TestA/x", want: []string{"./foo TestA/x false"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			i := slices.Index(treeKeys(nodes), tt.key)
+			if i < 0 {
+				t.Fatalf("node %q not found", tt.key)
+			}
+			got := make([]string, 0)
+			for _, target := range nodes[i].(*treeNode).targets() {
+				got = append(got, fmt.Sprintf("%s %s %t", target.PackageName, target.TestNamePattern, target.IsPrefix))
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("targets = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTreeFilterKeepsAncestors(t *testing.T) {
+	nodes := allTreeNodes(testTreeRoots())
+	targets := treeKeys(nodes)
+	filter := treeFilter(exactMatchFilter, nodes)
+
+	got := make([]string, 0)
+	for _, rank := range filter("TestC", targets) {
+		got = append(got, targets[rank.Index])
+	}
+	// ./foo is not an ancestor of ./foo/bar even though its name is a prefix
+	want := []string{"./foo/bar", "./foo/bar/bar_test.go", "./foo/bar/bar_test.go/TestC"}
+	if !slices.Equal(got, want) {
+		t.Errorf("filtered = %q, want %q", got, want)
+	}
+
+	got = got[:0]
+	for _, rank := range filter("x", targets) {
+		got = append(got, targets[rank.Index])
+	}
+	want = []string{"./foo", "./foo/foo_test.go", "./foo/foo_test.go/TestA", "./foo/foo_test.go/TestA/x"}
+	if !slices.Equal(got, want) {
+		t.Errorf("filtered = %q, want %q", got, want)
+	}
+}
+
+func TestTreeFilterKeepsAncestors_slashInSubtestName(t *testing.T) {
+	tests := map[string][]*tip.TestFunction{
+		"./foo/foo_test.go": {
+			{Name: "TestD", Kind: tip.TestKindTest, Subs: []*tip.SubTest{
+				{Name: "a", Resolved: true, Subs: []*tip.SubTest{{Name: "c", Resolved: true}}},
+				// go test reports t.Run("a/b", ...) as TestD/a/b, as if it were nested in a
+				{Name: "a/b", Resolved: true},
+			}},
+		},
+	}
+	nodes := allTreeNodes(toTreeNodes(tests, nil))
+	targets := treeKeys(nodes)
+	filter := treeFilter(exactMatchFilter, nodes)
+
+	got := make([]*treeNode, 0)
+	for _, rank := range filter("a/b", targets) {
+		got = append(got, nodes[rank.Index].(*treeNode))
+	}
+	want := []string{"./foo", "foo_test.go", "TestD", "a/b"}
+	labels := make([]string, 0, len(got))
+	for _, n := range got {
+		labels = append(labels, n.label)
+	}
+	if !slices.Equal(labels, want) {
+		t.Errorf("filtered = %q, want %q", labels, want)
+	}
+	if n := got[len(got)-1]; n.parent.label != "TestD" || n.name != "TestD/a/b" {
+		t.Errorf("subtest a/b is under %q as %q, want under TestD", n.parent.label, n.name)
+	}
+}
+
+func TestTreeExpandAndCollapse(t *testing.T) {
+	conf := &tip.Config{History: tip.HistoryConfig{DateFormat: "2006-01-02"}}
+	var m tea.Model = newModel([]list.Item{}, []list.Item{}, testTreeRoots(), nil, conf, nil, treeView, fuzzyMatchFilterType)
+	m, _ = m.Update(tea.WindowSizeMsg{Width: 80, Height: 24})
+
+	visible := func() []string {
+		return treeKeys(m.(model).treeList.Items())
+	}
+	if got, want := visible(), []string{"./foo", "./foo/bar"}; !slices.Equal(got, want) {
+		t.Fatalf("visible = %q, want %q", got, want)
+	}
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRight}) // expand ./foo
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRight}) // select foo_test.go
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRight}) // expand foo_test.go
+	if got, want := visible(), []string{"./foo", "./foo/foo_test.go", "./foo/foo_test.go/TestA", "./foo/foo_test.go/TestB", "./foo/bar"}; !slices.Equal(got, want) {
+		t.Fatalf("visible = %q, want %q", got, want)
+	}
+
+	// enter on a file runs all of its tests together
+	got, _ := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if targets := got.(model).retTargets; len(targets) != 2 {
+		t.Errorf("targets = %+v, want TestA/ and TestB", targets)
+	}
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyLeft}) // collapse foo_test.go
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyLeft}) // select ./foo
+	if got, want := m.(model).treeList.SelectedItem().(*treeNode).key, "./foo"; got != want {
+		t.Errorf("selected = %q, want %q", got, want)
+	}
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyLeft}) // collapse ./foo
+	if got, want := visible(), []string{"./foo", "./foo/bar"}; !slices.Equal(got, want) {
+		t.Errorf("visible = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,76 @@
+package watch
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+const inotifyMask = unix.IN_CLOSE_WRITE | unix.IN_CREATE | unix.IN_DELETE | unix.IN_MOVED_FROM | unix.IN_MOVED_TO
+
+// New starts watching Go files in the directories using inotify.
+func New(dirs []string, d time.Duration) (*Watcher, error) {
+	fd, err := unix.InotifyInit1(unix.IN_CLOEXEC | unix.IN_NONBLOCK)
+	if err != nil {
+		return nil, os.NewSyscallError("inotify_init1", err)
+	}
+	// a non-blocking file is handled by the runtime poller, so Close unblocks Read
+	f := os.NewFile(uintptr(fd), "inotify")
+
+	watchDirs := make(map[int]string)
+	for _, dir := range dirs {
+		wd, err := unix.InotifyAddWatch(fd, dir, inotifyMask)
+		if err != nil {
+			f.Close()
+			return nil, &os.PathError{Op: "inotify_add_watch", Path: dir, Err: err}
+		}
+		watchDirs[wd] = dir
+	}
+
+	events := make(chan string)
+	w := &Watcher{
+		changes: make(chan []string),
+		closer:  f.Close,
+		done:    make(chan struct{}),
+	}
+	go readEvents(f, watchDirs, events)
+	go debounce(events, w.changes, d, w.done)
+	return w, nil
+}
+
+func readEvents(f *os.File, watchDirs map[int]string, events chan<- string) {
+	defer close(events)
+
+	buf := make([]byte, 64*(unix.SizeofInotifyEvent+unix.NAME_MAX+1))
+	for {
+		n, err := f.Read(buf)
+		if err != nil {
+			// the watcher is closed, or there is no way to report the error other than stopping notifications
+			return
+		}
+		for offset := 0; offset+unix.SizeofInotifyEvent <= n; {
+			event := (*unix.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+			nameBytes := buf[offset+unix.SizeofInotifyEvent : offset+unix.SizeofInotifyEvent+int(event.Len)]
+			offset += unix.SizeofInotifyEvent + int(event.Len)
+
+			dir, ok := watchDirs[int(event.Wd)]
+			if !ok || event.Len == 0 {
+				continue
+			}
+			events <- filepath.Join(dir, cString(nameBytes))
+		}
+	}
+}
+
+// cString returns the string up to the first NUL, as names in inotify events are padded with NULs.
+func cString(b []byte) string {
+	for i, c := range b {
+		if c == 0 {
+			return string(b[:i])
+		}
+	}
+	return string(b)
+}
@@ -0,0 +1,14 @@
+//go:build !linux
+
+package watch
+
+import (
+	"errors"
+	"time"
+)
+
+// New starts watching Go files in the directories.
+// Watching is implemented with inotify, so it is only supported on Linux.
+func New(dirs []string, d time.Duration) (*Watcher, error) {
+	return nil, errors.New("watch mode is only supported on Linux")
+}
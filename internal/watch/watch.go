@@ -0,0 +1,74 @@
+package watch
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// DefaultDebounce is the quiet period to wait for before reporting changes,
+// so that saving several files at once triggers only one notification.
+const DefaultDebounce = 300 * time.Millisecond
+
+// Watcher notifies changes of Go files in the watched directories.
+type Watcher struct {
+	changes chan []string
+	closer  func() error
+	done    chan struct{}
+}
+
+// Changes returns a channel that receives the changed Go files after each quiet period.
+// The channel is closed when the watcher is closed.
+func (w *Watcher) Changes() <-chan []string {
+	return w.changes
+}
+
+func (w *Watcher) Close() error {
+	err := w.closer()
+	<-w.done
+	return err
+}
+
+// debounce collects file names from events and sends them to changes once no event arrives for d.
+// It keeps receiving events while waiting for the changes to be received, so that it never blocks closing.
+func debounce(events <-chan string, changes chan<- []string, d time.Duration, done chan<- struct{}) {
+	defer close(done)
+	defer close(changes)
+
+	var pending []string
+	ready := false
+	timer := time.NewTimer(d)
+	timer.Stop()
+	for {
+		var out chan<- []string
+		if ready {
+			out = changes
+		}
+		select {
+		case name, ok := <-events:
+			if !ok {
+				return
+			}
+			if !isGoFile(name) {
+				continue
+			}
+			if !slices.Contains(pending, name) {
+				pending = append(pending, name)
+			}
+			ready = false
+			timer.Reset(d)
+		case <-timer.C:
+			ready = len(pending) > 0
+		case out <- pending:
+			pending = nil
+			ready = false
+		}
+	}
+}
+
+func isGoFile(name string) bool {
+	base := filepath.Base(name)
+	// skip hidden files, such as temporary files of editors
+	return strings.HasSuffix(base, ".go") && !strings.HasPrefix(base, ".")
+}
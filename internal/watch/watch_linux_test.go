@@ -0,0 +1,70 @@
+package watch
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestWatcherReportsChangedGoFiles(t *testing.T) {
+	dir := t.TempDir()
+	w, err := New([]string{dir}, 50*time.Millisecond)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer w.Close()
+
+	for _, name := range []string{"a.go", "a_test.go", "README.md", ".#a.go"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("package a\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	select {
+	case got := <-w.Changes():
+		want := []string{filepath.Join(dir, "a.go"), filepath.Join(dir, "a_test.go")}
+		if !slices.Equal(got, want) {
+			t.Errorf("changes = %q, want %q", got, want)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no changes are reported")
+	}
+}
+
+func TestWatcherClose(t *testing.T) {
+	w, err := New([]string{t.TempDir()}, 50*time.Millisecond)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Errorf("Close() error = %v", err)
+	}
+	if _, ok := <-w.Changes(); ok {
+		t.Error("changes channel is not closed")
+	}
+}
+
+func TestWatcherCloseWithUnreceivedChanges(t *testing.T) {
+	dir := t.TempDir()
+	w, err := New([]string{dir}, 10*time.Millisecond)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "a.go"), []byte("package a\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	closed := make(chan struct{})
+	go func() {
+		w.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close() blocks while changes are not received")
+	}
+}